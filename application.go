@@ -14,7 +14,10 @@ var (
 	CONFIGURATION_FILE   = "config.yaml"
 )
 
-func initApplication() (Configuration, func()) {
+// initApplication prepares the application directory and loads the
+// configuration. An invalid configuration is returned as error, the
+// application directory is usable nonetheless.
+func initApplication() (Configuration, func(), error) {
 
 	if homedir, err := os.UserHomeDir(); err != nil {
 		fmt.Println("Failed to determine user home directory: ", err)
@@ -44,11 +47,13 @@ func initApplication() (Configuration, func()) {
 	getTemplatesPath()
 
 	cfg, err := loadConfiguration(configurationFile)
-	check(err)
+	if err != nil {
+		return cfg, func() { closeLogger() }, err
+	}
 
 	DATA_PATH = cfg.DataPath
 
-	return cfg, func() { closeLogger() }
+	return cfg, func() { closeLogger() }, nil
 }
 
 func getLogPath() string {
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// interceptAbort asks for confirmation before a running ceremony is aborted
+// when esc is pressed during data entry or evaluation. It reports whether
+// msg was consumed.
+func (m *Model) interceptAbort(msg tea.Msg) bool {
+	if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "esc" {
+		m.ConfirmAbort = true
+		return true
+	}
+	return false
+}
+
+func (m *Model) UpdateAbortConfirm(msg tea.Msg) []tea.Cmd {
+	cmds := []tea.Cmd{}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch strings.ToLower(msg.String()) {
+		case "j", "y":
+			m.endCeremony("Zertifizierung abgebrochen, alle Eingaben wurden verworfen.")
+			cmds = append(cmds, tea.ClearScreen)
+		case "n", "esc":
+			m.ConfirmAbort = false
+		}
+	}
+
+	return cmds
+}
+
+func (m *Model) ViewAbortConfirm() (header string, body string, footer string) {
+	s := m.Styles
+	header = "Zertifizierung abbrechen"
+
+	body = fmt.Sprintf("\nSoll die laufende Zertifizierung abgebrochen werden?\n\n%s\n",
+		s.Highlight.Render("Alle bisherigen Eingaben gehen verloren."))
+	footer = m.appErrorBoundaryView("j: Abbrechen und verwerfen · n/Esc: Weiter erfassen")
+
+	return header, body, footer
+}
+
+// issueCertificate creates the certificate of the finished ceremony and
+// returns to the main menu ready for the next ceremony. If the certificate
+// cannot be stored the ceremony stays in the summary so nothing is lost.
+func (m *Model) issueCertificate() {
+	applicant := m.applicantName
+
+	if err := m.recertificationError(); err != nil {
+		m.Summary.Error = err.Error()
+		return
+	}
+	if path := m.CreateCertificate(); path != "" {
+		m.endCeremony(fmt.Sprintf("Zertifikat für %s ausgestellt.", applicant))
+	} else {
+		m.Summary.Error = "Zertifikat konnte nicht gespeichert werden, Details im Log."
+	}
+}
+
+// endCeremony throws away the values and the draft of the running ceremony
+// and returns to the main menu showing notice.
+func (m *Model) endCeremony(notice string) {
+	removeDraft()
+	m.resetCeremony()
+	m.InitMenuModel()
+	m.State = STATE_MENU
+	m.Menu.Notice = notice
+}
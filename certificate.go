@@ -1,8 +1,10 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,23 +13,259 @@ import (
 )
 
 type Certificate struct {
-	ID         uuid.UUID             `yaml:"id"`
-	Date       time.Time             `yaml:"date"`
-	Applicant  string                `yaml:"applicant"`
-	ObjectName string                `yaml:"object_name"`
-	Reviewers  []string              `yaml:"reviewers"`
-	Questions  []CertificateQuestion `yaml:"questions"`
+	ID         uuid.UUID `yaml:"id" json:"id"`
+	Date       time.Time `yaml:"date" json:"date"`
+	Applicant  string    `yaml:"applicant" json:"applicant"`
+	ObjectName string    `yaml:"object_name" json:"object_name"`
+	Reviewers  []string  `yaml:"reviewers" json:"reviewers"`
+	// ReviewerIDs holds the roster IDs of Reviewers in the same order.
+	ReviewerIDs []string              `yaml:"reviewer_ids,omitempty" json:"reviewer_ids,omitempty"`
+	Questions   []CertificateQuestion `yaml:"questions" json:"questions"`
+	// Score is the breakdown computed when the certificate was issued.
+	// Certificates of older versions don't have one.
+	Score *ScoreBreakdown `yaml:"score,omitempty" json:"score,omitempty"`
+	// ValidUntil is the end of the validity of the level granted, nil if it
+	// doesn't expire.
+	ValidUntil *time.Time `yaml:"valid_until,omitempty" json:"valid_until,omitempty"`
+	// Recertifies is the ID of the certificate renewed by this one.
+	Recertifies string `yaml:"recertifies,omitempty" json:"recertifies,omitempty"`
+	// ObjectClass is the class of the object, which decided the evaluation.
+	ObjectClass string `yaml:"object_class,omitempty" json:"object_class,omitempty"`
+	// Track is the key of the track of the ceremony, see TrackConfig.
+	Track string `yaml:"track,omitempty" json:"track,omitempty"`
 }
 
+// CertificateQuestion holds the responses to one evaluation group. Groups
+// without a score field are recorded as Unscored and don't count towards
+// the result.
 type CertificateQuestion struct {
-	Question  string                `yaml:"question"`
-	Responses []CertificateResponse `yaml:"responses,omitempty"`
+	Question    string  `yaml:"question" json:"question"`
+	Key         string  `yaml:"key,omitempty" json:"key,omitempty"`
+	Unscored    bool    `yaml:"unscored,omitempty" json:"unscored,omitempty"`
+	Weight      float64 `yaml:"weight,omitempty" json:"weight,omitempty"`
+	Aggregation string  `yaml:"aggregation,omitempty" json:"aggregation,omitempty"`
+	// Scale is the scale of the score field, nil for the default scale.
+	Scale     *RatingScale          `yaml:"scale,omitempty" json:"scale,omitempty"`
+	Responses []CertificateResponse `yaml:"responses,omitempty" json:"responses,omitempty"`
 }
 
 type CertificateResponse struct {
-	Name    string `yaml:"name"`
-	Value   int    `yaml:"value"`
-	Comment string `yaml:"comment,omitempty"`
+	Name       string `yaml:"name" json:"name"`
+	ReviewerID string `yaml:"reviewer_id,omitempty" json:"reviewer_id,omitempty"`
+	// ReviewerWeight is the roster weight of the reviewer, 0 counts as 1.
+	ReviewerWeight float64 `yaml:"reviewer_weight,omitempty" json:"reviewer_weight,omitempty"`
+	Value          float64 `yaml:"value" json:"value"`
+	Comment        string  `yaml:"comment,omitempty" json:"comment,omitempty"`
+	// Answers holds the values of all fields of the group, including the
+	// score and comment above.
+	Answers []CertificateAnswer `yaml:"answers,omitempty" json:"answers,omitempty"`
+}
+
+// CertificateAnswer is the typed value of a single evaluation field: a
+// number for ranges and numbers, a bool for confirms, a list of strings for
+// multiselects and rankings, a rating per statement for likert fields, an
+// ISO date for dates and a string otherwise.
+type CertificateAnswer struct {
+	Key   string `yaml:"key" json:"key"`
+	Title string `yaml:"title" json:"title"`
+	Type  string `yaml:"type" json:"type"`
+	Role  string `yaml:"role,omitempty" json:"role,omitempty"`
+	Value any    `yaml:"value" json:"value"`
+	// Label is the value as shown to the reviewers, for ranges, rankings
+	// and likert fields only.
+	Label string `yaml:"label,omitempty" json:"label,omitempty"`
+}
+
+// Text renders the answer for display.
+func (a CertificateAnswer) Text() string {
+	if a.Label != "" {
+		return a.Label
+	}
+	return formatAnswer(a.Value)
+}
+
+// Answer returns the value of the field with the given key, or nil if the
+// reviewer left it empty.
+func (r CertificateResponse) Answer(key string) any {
+	for _, a := range r.Answers {
+		if a.Key == key {
+			return a.Value
+		}
+	}
+	return nil
+}
+
+// typedAnswer converts a form value into the value stored on the
+// certificate as described on CertificateAnswer. Empty values are reported
+// as not ok.
+func typedAnswer(fc FieldConfig, v any) (any, bool) {
+	switch t := v.(type) {
+	case nil:
+		return nil, false
+	case bool:
+		return t, true
+	case []string:
+		switch fc.Type {
+		case "ranking":
+			var placed []string
+			for _, v := range t {
+				if v != "" {
+					placed = append(placed, v)
+				}
+			}
+			return placed, len(placed) > 0
+		case "likert":
+			ratings := make(map[string]float64)
+			for i, v := range t {
+				if n, err := parseRating(v); err == nil && i < len(fc.Statements) {
+					ratings[fc.Statements[i]] = n
+				}
+			}
+			return ratings, len(ratings) > 0
+		}
+		return t, len(t) > 0
+	case string:
+		if t == "" {
+			return nil, false
+		}
+		switch fc.Type {
+		case "range":
+			if n, err := parseRating(t); err == nil {
+				return n, true
+			}
+		case "number":
+			if n, err := parseNumber(t); err == nil {
+				return n, true
+			}
+		case "date":
+			if d, err := parseDate(t); err == nil {
+				return d.Format(dateLayouts[0]), true
+			}
+		}
+		return t, true
+	default:
+		return t, true
+	}
+}
+
+// answerLabel renders a typed answer as shown to the reviewers: ranges on
+// their scale, rankings numbered by place and likert fields as one label per
+// statement. It returns "" for answers shown as their value.
+func answerLabel(fc FieldConfig, v any) string {
+	switch t := v.(type) {
+	case float64:
+		if fc.Type == "range" {
+			return fc.RatingScale().Format(t)
+		}
+	case []string:
+		if fc.Type == "ranking" {
+			parts := make([]string, len(t))
+			for i, o := range t {
+				parts[i] = fmt.Sprintf("%d. %s", i+1, o)
+			}
+			return strings.Join(parts, ", ")
+		}
+	case map[string]float64:
+		scale := fc.RatingScale()
+		var parts []string
+		for _, statement := range fc.Statements {
+			if n, ok := t[statement]; ok {
+				parts = append(parts, statement+": "+scale.Format(n))
+			}
+		}
+		return strings.Join(parts, "; ")
+	}
+	return ""
+}
+
+// formatAnswer renders an answer value for display: yes/no for bools and a
+// comma separated list for multiselects.
+func formatAnswer(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case bool:
+		if t {
+			return "Ja"
+		}
+		return "Nein"
+	case []string:
+		return strings.Join(t, ", ")
+	case []any:
+		parts := make([]string, len(t))
+		for i, e := range t {
+			parts[i] = fmt.Sprint(e)
+		}
+		return strings.Join(parts, ", ")
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = fmt.Sprintf("%s: %v", k, t[k])
+		}
+		return strings.Join(parts, "; ")
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// QuestionSummary holds the aggregated responses to a single question.
+type QuestionSummary struct {
+	Question string  `json:"question"`
+	Avg      float64 `json:"avg"`
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	Count    int     `json:"count"`
+}
+
+// breakdown returns the score breakdown stored on the certificate. For
+// certificates without one it is computed from the responses.
+func (c Certificate) breakdown(skillLevels []SkillLevelConfig) ScoreBreakdown {
+	if c.Score != nil {
+		return *c.Score
+	}
+	return scoreQuestions(c.Questions, Configuration{SkillLevels: skillLevels}, nil)
+}
+
+// summarizeCertificate returns the per-question summaries (avg, min, max)
+// and the total score of the certificate.
+func summarizeCertificate(cert Certificate) ([]QuestionSummary, float64) {
+	b := cert.breakdown(nil)
+
+	var summaries []QuestionSummary
+	for _, g := range b.Groups {
+		summaries = append(summaries, QuestionSummary{
+			Question: g.Title,
+			Avg:      g.Avg,
+			Min:      g.Min,
+			Max:      g.Max,
+			Count:    len(g.Values),
+		})
+	}
+	return summaries, b.Total
+}
+
+// rankForScore determines the rank from configured skill levels (mirrors
+// summary.go logic). skillLevels may be empty; in that case the rank is empty.
+func rankForScore(skillLevels []SkillLevelConfig, score float64) string {
+	_, rank := skillLevelForScore(skillLevels, score)
+	return rank
+}
+
+// skillLevelForScore returns the index into skillLevels and the name of the
+// highest level reached by score, or -1 and "" if none was reached.
+func skillLevelForScore(skillLevels []SkillLevelConfig, score float64) (int, string) {
+	idx, rank := -1, ""
+	for i, level := range skillLevels {
+		if score >= float64(level.MinPoints) {
+			idx, rank = i, level.Name
+		}
+	}
+	return idx, rank
 }
 
 func loadCertificate(stateFile string) (Certificate, error) {
@@ -69,6 +307,11 @@ func saveCertificate(path string, cert Certificate) error {
 		} else {
 			logger.Printf("Certificate saved at `%s`.\n", path)
 		}
+
+		if err := indexCertificate(path); err != nil {
+			logger.Printf("Failed to update certificate index: %v\n", err)
+		}
+
 		return nil
 	}
 }
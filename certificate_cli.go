@@ -0,0 +1,370 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// certificateFilterFlags registers the listing filters shared by the `list`
+// and `export` commands and returns a function building the filter once the
+// flags have been parsed.
+func certificateFilterFlags(cfg Configuration, flags *flag.FlagSet) func() (CertificateFilter, error) {
+	from := flags.String("from", "", "only certificates issued on or after this date (YYYY-MM-DD)")
+	to := flags.String("to", "", "only certificates issued on or before this date (YYYY-MM-DD)")
+	applicant := flags.String("applicant", "", "only certificates whose applicant contains this text")
+	object := flags.String("object", "", "only certificates whose object contains this text")
+	reviewer := flags.String("reviewer", "", "only certificates reviewed by this reviewer (name or initials)")
+	buildTrack := trackFlag(cfg, flags)
+
+	return func() (CertificateFilter, error) {
+		filter := CertificateFilter{
+			Applicant: *applicant,
+			Object:    *object,
+		}
+		if err := buildTrack(&filter); err != nil {
+			return filter, err
+		}
+		if *reviewer != "" {
+			roster, err := loadRoster()
+			if err != nil {
+				return filter, err
+			}
+			filter.Reviewer = roster.find(*reviewer)
+			if filter.Reviewer == nil {
+				filter.Reviewer = &RosterEntry{Name: *reviewer}
+			}
+		}
+		if *from != "" {
+			t, err := time.ParseInLocation("2006-01-02", *from, time.Local)
+			if err != nil {
+				return filter, fmt.Errorf("invalid --from date %q: %w", *from, err)
+			}
+			filter.From = t
+		}
+		if *to != "" {
+			t, err := time.ParseInLocation("2006-01-02", *to, time.Local)
+			if err != nil {
+				return filter, fmt.Errorf("invalid --to date %q: %w", *to, err)
+			}
+			// include the whole day
+			filter.To = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		return filter, nil
+	}
+}
+
+// trackFlag registers the --track filter and returns a function setting it
+// on a filter once the flags have been parsed.
+func trackFlag(cfg Configuration, flags *flag.FlagSet) func(*CertificateFilter) error {
+	track := flags.String("track", "", "only certificates on this track (key or name)")
+
+	return func(filter *CertificateFilter) error {
+		if *track == "" {
+			return nil
+		}
+		t, ok := cfg.findTrack(*track)
+		if !ok || len(cfg.Tracks) == 0 {
+			return fmt.Errorf("unknown track %q", *track)
+		}
+		filter.Track = t.Key
+		filter.tracks = cfg
+		return nil
+	}
+}
+
+// parseArgs parses flags which may be given before or after positional
+// arguments (e.g. `show <id> --format yaml`) and returns the positional ones.
+func parseArgs(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// runList implements the `list` command. With tracks configured the track
+// of each certificate is listed as well.
+func runList(cfg Configuration, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	buildFilter := certificateFilterFlags(cfg, flags)
+	limit := flags.Int("limit", 0, "maximum number of certificates to list (0 = all)")
+	if _, err := parseArgs(flags, args); err != nil {
+		return err
+	}
+
+	filter, err := buildFilter()
+	if err != nil {
+		return err
+	}
+
+	all, err := findLatestCertificates(0)
+	if err != nil {
+		return err
+	}
+
+	list := filterCertificates(all, filter)
+	if *limit > 0 && len(list) > *limit {
+		list = list[:*limit]
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	if len(cfg.Tracks) > 0 {
+		fmt.Fprintln(w, "ID\tDATUM\tANTRAGSTELLER\tOBJEKT\tLAUFBAHN")
+		for _, s := range list {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.ID(), s.Date.Format("2006-01-02"), s.Applicant, s.ObjectName, cfg.TrackOf(s.Track).Title())
+		}
+		return w.Flush()
+	}
+	fmt.Fprintln(w, "ID\tDATUM\tANTRAGSTELLER\tOBJEKT")
+	for _, s := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.ID(), s.Date.Format("2006-01-02"), s.Applicant, s.ObjectName)
+	}
+	return w.Flush()
+}
+
+// runShow implements the `show` command.
+func runShow(cfg Configuration, args []string) error {
+	flags := flag.NewFlagSet("show", flag.ContinueOnError)
+	format := flags.String("format", "table", "output format: table or yaml")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("show: expected exactly one certificate id")
+	}
+
+	sel, err := findCertificate(positional[0])
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "yaml":
+		data, err := os.ReadFile(sel.Path)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	case "table":
+		cert, err := loadCertificate(sel.Path)
+		if err != nil {
+			return err
+		}
+		return writeCertificateTable(os.Stdout, cert, cfg)
+	default:
+		return fmt.Errorf("show: unknown format %q", *format)
+	}
+}
+
+func writeCertificateTable(out io.Writer, cert Certificate, cfg Configuration) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(w, "ID:\t%s\n", cert.ID)
+	fmt.Fprintf(w, "Datum:\t%s\n", cert.Date.Format("2006-01-02 15:04"))
+	fmt.Fprintf(w, "Antragsteller:\t%s\n", cert.Applicant)
+	fmt.Fprintf(w, "Objekt:\t%s\n", cert.ObjectName)
+	if cert.ObjectClass != "" {
+		fmt.Fprintf(w, "Klasse:\t%s\n", cert.ObjectClass)
+	}
+	if len(cfg.Tracks) > 0 {
+		fmt.Fprintf(w, "Laufbahn:\t%s\n", cfg.TrackOf(cert.Track).Title())
+	}
+	fmt.Fprintf(w, "Zertifizierer:\t%s\n", strings.Join(cert.Reviewers, ", "))
+	if cert.ValidUntil != nil {
+		fmt.Fprintf(w, "Gültig bis:\t%s\n", cert.ValidUntil.Format("2006-01-02"))
+	}
+	if cert.Recertifies != "" {
+		fmt.Fprintf(w, "Rezertifiziert:\t%s\n", cert.Recertifies)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "FRAGE\tZERTIFIZIERER\tWERT\tKOMMENTAR")
+	for _, q := range cert.Questions {
+		for _, r := range q.Responses {
+			value := scaleOrDefault(q.Scale).Format(r.Value)
+			if q.Unscored {
+				value = "-"
+			}
+			comment := strings.ReplaceAll(r.Comment, "\n", " ")
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", q.Question, r.Name, value, comment)
+			for _, a := range r.Answers {
+				if a.Role != "" {
+					continue
+				}
+				fmt.Fprintf(w, "\t\t\t%s: %s\n", a.Title, a.Text())
+			}
+		}
+	}
+
+	return w.Flush()
+}
+
+// runRender implements the `render` command. Unlike printing from the TUI
+// the generated file is not opened.
+func runRender(cfg Configuration, args []string) error {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	outDir := flags.String("out", "", "output directory (default: next to the certificate)")
+	positional, err := parseArgs(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("render: expected exactly one certificate id")
+	}
+
+	sel, err := findCertificate(positional[0])
+	if err != nil {
+		return err
+	}
+
+	cert, err := loadCertificate(sel.Path)
+	if err != nil {
+		return err
+	}
+
+	srcDir := filepath.Dir(sel.Path)
+	dstDir := srcDir
+	if *outDir != "" {
+		dstDir = *outDir
+	}
+	outputBase := sel.ID()
+
+	// the template references the object image by its basename, so it has
+	// to be placed next to the rendered output
+	if dstDir != srcDir {
+		if err := os.MkdirAll(dstDir, os.ModePerm); err != nil {
+			return err
+		}
+		img := filepath.Join(srcDir, outputBase+".png")
+		if _, err := os.Stat(img); err == nil {
+			if err := copyFile(img, filepath.Join(dstDir, outputBase+".png")); err != nil {
+				return err
+			}
+		}
+	}
+
+	out, err := GenerateCertificatePDF(cert, dstDir, outputBase, cfg.TrackOf(cert.Track))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(out)
+	return nil
+}
+
+// runExport implements the `export` command which writes the responses of
+// all matching certificates as CSV (one row per response), JSON or YAML.
+func runExport(cfg Configuration, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	buildFilter := certificateFilterFlags(cfg, flags)
+	format := flags.String("format", "csv", "output format: csv, json or yaml")
+	outPath := flags.String("out", "", "output file (default: stdout)")
+	if _, err := parseArgs(flags, args); err != nil {
+		return err
+	}
+
+	filter, err := buildFilter()
+	if err != nil {
+		return err
+	}
+
+	all, err := findLatestCertificates(0)
+	if err != nil {
+		return err
+	}
+
+	certs := []Certificate{}
+	for _, s := range filterCertificates(all, filter) {
+		cert, err := loadCertificate(s.Path)
+		if err != nil {
+			return fmt.Errorf("failed to load certificate %s: %w", s.Path, err)
+		}
+		certs = append(certs, cert)
+	}
+
+	var out io.Writer = os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	switch *format {
+	case "csv":
+		w := csv.NewWriter(out)
+		_ = w.Write([]string{"id", "date", "applicant", "object_name", "track", "question", "reviewer", "value", "comment"})
+		for _, cert := range certs {
+			for _, q := range cert.Questions {
+				for _, r := range q.Responses {
+					value := formatRating(r.Value)
+					if q.Unscored {
+						value = ""
+					}
+					_ = w.Write([]string{
+						cert.ID.String(),
+						cert.Date.Format(time.RFC3339),
+						cert.Applicant,
+						cert.ObjectName,
+						cfg.TrackOf(cert.Track).Key,
+						q.Question,
+						r.Name,
+						value,
+						r.Comment,
+					})
+				}
+			}
+		}
+		w.Flush()
+		return w.Error()
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(certs)
+	case "yaml":
+		return yaml.NewEncoder(out).Encode(certs)
+	default:
+		return fmt.Errorf("export: unknown format %q", *format)
+	}
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+
+	return out.Close()
+}
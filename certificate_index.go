@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+var (
+	CERTIFICATE_INDEX_FILE = "certificates.index.json"
+)
+
+// certificateIndexVersion is stored in the index file. Bump it whenever the
+// content of CertificateSummary changes so existing indexes get rebuilt.
+const certificateIndexVersion = 7
+
+// certificateIndexEntry is a summary of one certificate YAML together with
+// the file state it was read from.
+type certificateIndexEntry struct {
+	CertificateSummary
+	ModTime time.Time `json:"mod_time"`
+	Size    int64     `json:"size"`
+}
+
+// certificateIndex caches the summaries of all certificates so listings
+// don't need to parse every YAML of the archive. It is stored as JSON in the
+// data folder; entries are keyed by the path relative to the certificates
+// path.
+type certificateIndex struct {
+	Version int                              `json:"version"`
+	Entries map[string]certificateIndexEntry `json:"entries"`
+}
+
+func getCertificateIndexPath() string {
+	return path.Join(getDataPath(), CERTIFICATE_INDEX_FILE)
+}
+
+// readCertificateIndex reads the index file as is. A missing, unreadable or
+// outdated index yields an empty index.
+func readCertificateIndex() *certificateIndex {
+	idx := &certificateIndex{
+		Version: certificateIndexVersion,
+		Entries: make(map[string]certificateIndexEntry),
+	}
+
+	data, err := os.ReadFile(getCertificateIndexPath())
+	if err != nil {
+		return idx
+	}
+
+	var stored certificateIndex
+	if err := json.Unmarshal(data, &stored); err != nil {
+		logger.Printf("Failed to parse certificate index, rebuilding: %v", err)
+		return idx
+	}
+	if stored.Version != certificateIndexVersion || stored.Entries == nil {
+		logger.Printf("Certificate index version %d outdated, rebuilding.", stored.Version)
+		return idx
+	}
+
+	return &stored
+}
+
+func (idx *certificateIndex) save() error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so an interrupted write never leaves a
+	// truncated index behind
+	tmp := getCertificateIndexPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, getCertificateIndexPath())
+}
+
+// sync brings the index up to date with the certificates on disk: files
+// whose size or mtime differ from the indexed state are read again, new
+// files are added and entries of removed files are dropped. It reports
+// whether the index changed.
+func (idx *certificateIndex) sync() (bool, error) {
+	base := getCertificatesPath()
+	changed := false
+	seen := make(map[string]bool, len(idx.Entries))
+
+	err := filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if filepath.Ext(p) != ".yaml" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			return nil
+		}
+		seen[rel] = true
+
+		fi, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		if e, ok := idx.Entries[rel]; ok && e.Size == fi.Size() && e.ModTime.Equal(fi.ModTime()) {
+			return nil
+		}
+
+		summary, err := readCertificateSummary(p)
+		if err != nil {
+			logger.Printf("Failed to index certificate %s: %v", p, err)
+			return nil
+		}
+
+		idx.Entries[rel] = certificateIndexEntry{
+			CertificateSummary: summary,
+			ModTime:            fi.ModTime(),
+			Size:               fi.Size(),
+		}
+		changed = true
+
+		return nil
+	})
+	if err != nil {
+		return changed, err
+	}
+
+	for rel := range idx.Entries {
+		if !seen[rel] {
+			delete(idx.Entries, rel)
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
+
+// summaries returns the indexed summaries with their absolute paths.
+func (idx *certificateIndex) summaries() []CertificateSummary {
+	base := getCertificatesPath()
+
+	res := make([]CertificateSummary, 0, len(idx.Entries))
+	for rel, e := range idx.Entries {
+		s := e.CertificateSummary
+		s.Path = filepath.Join(base, rel)
+		res = append(res, s)
+	}
+	return res
+}
+
+// loadCertificateIndex returns the certificate index, verified against the
+// files on disk. Changes found while verifying are persisted.
+func loadCertificateIndex() (*certificateIndex, error) {
+	idx := readCertificateIndex()
+
+	changed, err := idx.sync()
+	if err != nil {
+		return nil, err
+	}
+
+	if changed {
+		if err := idx.save(); err != nil {
+			logger.Printf("Failed to save certificate index: %v", err)
+		}
+	}
+
+	return idx, nil
+}
+
+// rebuildCertificateIndex discards the stored index and reads all
+// certificates again.
+func rebuildCertificateIndex() (*certificateIndex, error) {
+	idx := &certificateIndex{
+		Version: certificateIndexVersion,
+		Entries: make(map[string]certificateIndexEntry),
+	}
+
+	if _, err := idx.sync(); err != nil {
+		return nil, err
+	}
+
+	return idx, idx.save()
+}
+
+// indexCertificate adds or updates the index entry of a single certificate
+// file, e.g. after it has been saved.
+func indexCertificate(p string) error {
+	base := getCertificatesPath()
+	rel, err := filepath.Rel(base, p)
+	if err != nil || !filepath.IsLocal(rel) {
+		return fmt.Errorf("certificate %s is not located in %s", p, base)
+	}
+
+	fi, err := os.Stat(p)
+	if err != nil {
+		return err
+	}
+
+	summary, err := readCertificateSummary(p)
+	if err != nil {
+		return err
+	}
+
+	idx := readCertificateIndex()
+	idx.Entries[rel] = certificateIndexEntry{
+		CertificateSummary: summary,
+		ModTime:            fi.ModTime(),
+		Size:               fi.Size(),
+	}
+
+	return idx.save()
+}
+
+// runIndex implements the `index` command which verifies (or with `rebuild`
+// recreates) the certificate index.
+func runIndex(args []string) error {
+	var (
+		idx *certificateIndex
+		err error
+	)
+
+	switch {
+	case len(args) == 0:
+		idx, err = loadCertificateIndex()
+	case len(args) == 1 && args[0] == "rebuild":
+		idx, err = rebuildCertificateIndex()
+	default:
+		return fmt.Errorf("index: expected no argument or `rebuild`")
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d Zertifikate im Index %s\n", len(idx.Entries), getCertificateIndexPath())
+	return nil
+}
@@ -16,8 +16,9 @@ import (
 // in the application's templates directory and then converts it to PDF.
 // The template is editable by the user at templates/certificate.html.
 // It prefers to use `wkhtmltopdf` if installed; otherwise it writes the HTML
-// next to the YAML so users can manually convert.
-func GenerateCertificatePDF(cert Certificate, basePath string, outputBaseName string, skillLevels []SkillLevelConfig) (string, error) {
+// next to the YAML so users can manually convert. track is the track of the
+// certificate, see Configuration.TrackOf.
+func GenerateCertificatePDF(cert Certificate, basePath string, outputBaseName string, track TrackConfig) (string, error) {
 	var tplPath = filepath.Join(getAppBasePath(), "templates", "certificate.html")
 	localTplPath := filepath.Join(getTemplatesPath(), "certificate.html")
 
@@ -40,12 +41,12 @@ func GenerateCertificatePDF(cert Certificate, basePath string, outputBaseName st
 			}
 			return s[start:end]
 		},
-		"stars": func(n int) string {
-			if n <= 0 {
-				return ""
-			}
-			return strings.Repeat("⭐", n)
+		"stars": stars,
+		// rating renders a value in the display style of a question's scale
+		"rating": func(s *RatingScale, v float64) string {
+			return scaleOrDefault(s).Format(v)
 		},
+		"answer": formatAnswer,
 		"initials": func(s string) string {
 			s = strings.TrimSpace(s)
 			if s == "" {
@@ -79,74 +80,27 @@ func GenerateCertificatePDF(cert Certificate, basePath string, outputBaseName st
 		name = cert.ID.String()
 	}
 
-	// prepare per-question summaries (avg, min, max)
-	type questionSummary struct {
-		Question string
-		Avg      float64
-		Min      int
-		Max      int
-		Count    int
-	}
-
-	var summaries []questionSummary
-	overallSum := 0
-	overallCount := 0
-	for _, q := range cert.Questions {
-		min := 1 << 30
-		max := -1 << 30
-		sum := 0
-		count := 0
-		for _, r := range q.Responses {
-			v := r.Value
-			sum += v
-			count++
-			if v < min {
-				min = v
-			}
-			if v > max {
-				max = v
-			}
-		}
-		overallSum += sum
-		overallCount += count
-		if count == 0 {
-			min = 0
-			max = 0
-		}
-		avg := 0.0
-		if count > 0 {
-			avg = float64(sum) / float64(count)
-		}
-		summaries = append(summaries, questionSummary{Question: q.Question, Avg: avg, Min: min, Max: max, Count: count})
-	}
-
-	overallAvg := 0.0
-	if overallCount > 0 {
-		overallAvg = float64(overallSum) / float64(overallCount)
-	}
-
-	// determine rank from configured skill levels (mirrors summary.go logic)
-	rank := ""
-	// skillLevels may be empty; in that case leave rank empty
-	for _, level := range skillLevels {
-		if overallAvg >= float64(level.MinPoints) {
-			rank = level.Name
-		}
-	}
+	score := cert.breakdown(track.SkillLevels)
+	summaries, _ := summarizeCertificate(cert)
 
-	// prepare template data with optional ImageFile, summaries, overall average and rank
+	// prepare template data with optional ImageFile, summaries, the score
+	// breakdown, overall score, rank and the name of the track
 	data := struct {
 		Certificate
 		ImageFile  string
-		Summaries  []questionSummary
+		Summaries  []QuestionSummary
+		Score      ScoreBreakdown
 		OverallAvg float64
 		Rank       string
+		TrackName  string
 	}{
 		Certificate: cert,
 		ImageFile:   "",
 		Summaries:   summaries,
-		OverallAvg:  overallAvg,
-		Rank:        rank,
+		Score:       score,
+		OverallAvg:  score.Total,
+		Rank:        score.Level,
+		TrackName:   track.Title(),
 	}
 
 	// if a PNG with the same base name exists in basePath, reference it
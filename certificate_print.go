@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -11,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"gopkg.in/yaml.v3"
@@ -18,84 +18,402 @@ import (
 
 // CertificateSummary is a lightweight view of a certificate used for listing.
 type CertificateSummary struct {
-	Path       string
-	Name       string
-	Date       time.Time
-	Applicant  string
-	ObjectName string
+	Path        string    `json:"-"`
+	Name        string    `json:"name"`
+	Date        time.Time `json:"date"`
+	Applicant   string    `json:"applicant"`
+	ObjectName  string    `json:"object_name"`
+	Reviewers   []string  `json:"reviewers,omitempty"`
+	ReviewerIDs []string  `json:"reviewer_ids,omitempty"`
+	Score       float64   `json:"score"`
+	// Rank is the skill level stored on the certificate, empty for
+	// certificates without a score breakdown.
+	Rank      string            `json:"rank,omitempty"`
+	Questions []QuestionSummary `json:"questions,omitempty"`
+	// ValidUntil, Recertifies, ObjectClass and Track are taken over from the
+	// certificate.
+	ValidUntil  *time.Time `json:"valid_until,omitempty"`
+	Recertifies string     `json:"recertifies,omitempty"`
+	ObjectClass string     `json:"object_class,omitempty"`
+	Track       string     `json:"track,omitempty"`
 }
 
-// findLatestCertificates scans the certificates directory under the application
-// certificates path and returns the most recent `limit` certificates sorted
-// descending by date where possible. If the YAML contains a `date` field it
-// will be used; otherwise the file mod time is used.
-func findLatestCertificates(limit int) ([]CertificateSummary, error) {
-	base := getCertificatesPath()
-	var summaries []CertificateSummary
+// ID returns the certificate id, which is the basename of the YAML file.
+func (s CertificateSummary) ID() string {
+	return strings.TrimSuffix(s.Name, filepath.Ext(s.Name))
+}
 
-	_ = filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
-		if err != nil || d.IsDir() {
-			return nil
+// CertificateFilter narrows down a certificate listing. Zero values match
+// everything; text filters are case-insensitive substring matches and the
+// date range is inclusive.
+type CertificateFilter struct {
+	From      time.Time
+	To        time.Time
+	Applicant string
+	Object    string
+	// Reviewer matches certificates reviewed by the roster entry, under
+	// whatever name it had at the time, or by name for unknown reviewers.
+	Reviewer *RosterEntry
+	// Track matches certificates on the track with this key, tracks
+	// resolves the tracks recorded on the certificates, see
+	// Configuration.TrackOf.
+	Track  string
+	tracks Configuration
+}
+
+func (f CertificateFilter) matchesReviewer(s CertificateSummary) bool {
+	for _, id := range s.ReviewerIDs {
+		if id == f.Reviewer.ID {
+			return true
 		}
-		if filepath.Ext(p) != ".yaml" {
-			return nil
+	}
+	// certificates from before the roster only know the names
+	if len(s.ReviewerIDs) == 0 {
+		for _, name := range s.Reviewers {
+			if strings.EqualFold(name, f.Reviewer.Name) {
+				return true
+			}
 		}
+	}
+	return false
+}
 
-		data, err := os.ReadFile(p)
-		if err != nil {
-			return nil
-		}
+func (f CertificateFilter) Matches(s CertificateSummary) bool {
+	if !f.From.IsZero() && s.Date.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && s.Date.After(f.To) {
+		return false
+	}
+	if f.Applicant != "" && !strings.Contains(strings.ToLower(s.Applicant), strings.ToLower(f.Applicant)) {
+		return false
+	}
+	if f.Object != "" && !strings.Contains(strings.ToLower(s.ObjectName), strings.ToLower(f.Object)) {
+		return false
+	}
+	if f.Reviewer != nil && !f.matchesReviewer(s) {
+		return false
+	}
+	if f.Track != "" && f.tracks.TrackOf(s.Track).Key != f.Track {
+		return false
+	}
+	return true
+}
 
-		// Try to unmarshal date/applicant/object_name from YAML into small struct
-		var meta struct {
-			Date       time.Time `yaml:"date"`
-			Applicant  string    `yaml:"applicant"`
-			ObjectName string    `yaml:"object_name"`
+// filterCertificates returns the summaries matching the filter, keeping
+// their order.
+func filterCertificates(summaries []CertificateSummary, filter CertificateFilter) []CertificateSummary {
+	var res []CertificateSummary
+	for _, s := range summaries {
+		if filter.Matches(s) {
+			res = append(res, s)
 		}
-		var usedDate time.Time
-		if err := yaml.Unmarshal(data, &meta); err == nil {
-			usedDate = meta.Date
+	}
+	return res
+}
+
+// findCertificate looks up a single certificate by (a prefix of) its id.
+func findCertificate(idPrefix string) (CertificateSummary, error) {
+	idPrefix = strings.ToLower(strings.TrimSpace(idPrefix))
+	if idPrefix == "" {
+		return CertificateSummary{}, fmt.Errorf("no certificate id given")
+	}
+
+	all, err := findLatestCertificates(0)
+	if err != nil {
+		return CertificateSummary{}, err
+	}
+
+	var matches []CertificateSummary
+	for _, s := range all {
+		if strings.HasPrefix(strings.ToLower(s.ID()), idPrefix) {
+			matches = append(matches, s)
 		}
+	}
 
-		if usedDate.IsZero() {
-			if fi, err := os.Stat(p); err == nil {
-				usedDate = fi.ModTime()
-			}
+	switch len(matches) {
+	case 0:
+		return CertificateSummary{}, fmt.Errorf("no certificate found for id %q", idPrefix)
+	case 1:
+		return matches[0], nil
+	default:
+		var ids []string
+		for _, s := range matches {
+			ids = append(ids, s.ID())
 		}
+		return CertificateSummary{}, fmt.Errorf("id %q is ambiguous: %s", idPrefix, strings.Join(ids, ", "))
+	}
+}
 
-		name := filepath.Base(p)
-		summaries = append(summaries, CertificateSummary{
-			Path:       p,
-			Name:       name,
-			Date:       usedDate,
-			Applicant:  meta.Applicant,
-			ObjectName: meta.ObjectName,
-		})
+// findLatestCertificates returns the most recent `limit` certificates of the
+// certificates path sorted descending by date. A limit <= 0 returns all
+// certificates. The listing is served from the certificate index which is
+// brought up to date with the files on disk first.
+func findLatestCertificates(limit int) ([]CertificateSummary, error) {
+	idx, err := loadCertificateIndex()
+	if err != nil {
+		return nil, err
+	}
 
-		return nil
-	})
+	summaries := idx.summaries()
 
 	sort.Slice(summaries, func(i, j int) bool {
 		return summaries[i].Date.After(summaries[j].Date)
 	})
 
-	if len(summaries) > limit {
+	if limit > 0 && len(summaries) > limit {
 		summaries = summaries[:limit]
 	}
 	return summaries, nil
 }
 
+// readCertificateSummary reads the certificate YAML at p into a summary. If
+// the YAML contains a `date` field it will be used; otherwise the file mod
+// time is used.
+func readCertificateSummary(p string) (CertificateSummary, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return CertificateSummary{}, err
+	}
+
+	var meta Certificate
+	var usedDate time.Time
+	if err := yaml.Unmarshal(data, &meta); err == nil {
+		usedDate = meta.Date
+	}
+	questions, score := summarizeCertificate(meta)
+	rank := ""
+	if meta.Score != nil {
+		rank = meta.Score.Level
+	}
+
+	if usedDate.IsZero() {
+		if fi, err := os.Stat(p); err == nil {
+			usedDate = fi.ModTime()
+		}
+	}
+
+	name := filepath.Base(p)
+	return CertificateSummary{
+		Path:        p,
+		Name:        name,
+		Date:        usedDate,
+		Applicant:   meta.Applicant,
+		ObjectName:  meta.ObjectName,
+		Reviewers:   meta.Reviewers,
+		ReviewerIDs: meta.ReviewerIDs,
+		Score:       score,
+		Rank:        rank,
+		Questions:   questions,
+		ValidUntil:  meta.ValidUntil,
+		Recertifies: meta.Recertifies,
+		ObjectClass: meta.ObjectClass,
+		Track:       meta.Track,
+	}, nil
+}
+
+const (
+	printPageSize    = 10
+	printListWidth   = 44
+	printDetailWidth = 28
+)
+
+// PrintSort is the ordering of the certificate archive in the print view.
+type PrintSort int
+
+const (
+	SORT_BY_DATE PrintSort = iota
+	SORT_BY_SCORE
+	SORT_BY_RANK
+)
+
+func (s PrintSort) String() string {
+	switch s {
+	case SORT_BY_SCORE:
+		return "Bewertung"
+	case SORT_BY_RANK:
+		return "Rang"
+	default:
+		return "Datum"
+	}
+}
+
+// PrintModel holds the state of the certificate archive browser.
+type PrintModel struct {
+	// All holds the whole archive, List the filtered and sorted entries shown.
+	All   []CertificateSummary
+	List  []CertificateSummary
+	Index int
+
+	Filter    textinput.Model
+	Filtering bool
+	Sort      PrintSort
+	Ascending bool
+}
+
 func (m *Model) InitPrintModel() {
-	list, err := findLatestCertificates(5)
+	filter := textinput.New()
+	filter.Prompt = "Filter: "
+	filter.Placeholder = "Name, Objekt, a:… o:… t:… d:2024-01..2024-06"
+	filter.Width = printListWidth - len(filter.Prompt) - 1
+
+	m.Print = PrintModel{
+		Filter: filter,
+		Sort:   SORT_BY_DATE,
+	}
+
+	list, err := findLatestCertificates(0)
 	if err != nil {
 		logger.Printf("Failed to load certificate list: %v", err)
-		m.PrintList = []CertificateSummary{}
-		return
+		list = []CertificateSummary{}
 	}
-	m.PrintList = list
-	if m.PrintIndex >= len(m.PrintList) {
-		m.PrintIndex = 0
+	m.Print.All = list
+	m.applyPrintFilter()
+}
+
+// archiveQuery is the parsed filter text of the print view. Terms prefixed
+// with `a:` match the applicant, `o:` the object, `t:` the key or name of
+// the track and `d:` the date (either a prefix like `2024-05` or a range
+// like `2024-01..2024-06`); other terms match the applicant, object or date.
+type archiveQuery struct {
+	applicant []string
+	object    []string
+	tracks    []string
+	dates     []string
+	any       []string
+}
+
+func parseArchiveQuery(text string) archiveQuery {
+	var q archiveQuery
+	for _, term := range strings.Fields(strings.ToLower(text)) {
+		switch {
+		case strings.HasPrefix(term, "a:"):
+			q.applicant = append(q.applicant, strings.TrimPrefix(term, "a:"))
+		case strings.HasPrefix(term, "o:"):
+			q.object = append(q.object, strings.TrimPrefix(term, "o:"))
+		case strings.HasPrefix(term, "t:"):
+			q.tracks = append(q.tracks, strings.TrimPrefix(term, "t:"))
+		case strings.HasPrefix(term, "d:"):
+			q.dates = append(q.dates, strings.TrimPrefix(term, "d:"))
+		default:
+			q.any = append(q.any, term)
+		}
 	}
+	return q
+}
+
+// matchesDate reports whether the ISO formatted date matches a `d:` term.
+// Dates compare as strings, a partial bound like `2024-06` covers the whole
+// month.
+func matchesDate(date string, term string) bool {
+	from, to, isRange := strings.Cut(term, "..")
+	if !isRange {
+		return strings.HasPrefix(date, term)
+	}
+	if from != "" && date < from {
+		return false
+	}
+	if to != "" && date[:min(len(date), len(to))] > to {
+		return false
+	}
+	return true
+}
+
+// matches reports whether the certificate on the given track matches the
+// query.
+func (q archiveQuery) matches(s CertificateSummary, track TrackConfig) bool {
+	applicant := strings.ToLower(s.Applicant)
+	object := strings.ToLower(s.ObjectName)
+	date := s.Date.Format("2006-01-02")
+
+	for _, t := range q.applicant {
+		if !strings.Contains(applicant, t) {
+			return false
+		}
+	}
+	for _, t := range q.object {
+		if !strings.Contains(object, t) {
+			return false
+		}
+	}
+	for _, t := range q.tracks {
+		if !strings.Contains(strings.ToLower(track.Key), t) && !strings.Contains(strings.ToLower(track.Name), t) {
+			return false
+		}
+	}
+	for _, t := range q.dates {
+		if !matchesDate(date, t) {
+			return false
+		}
+	}
+	for _, t := range q.any {
+		if !strings.Contains(applicant, t) && !strings.Contains(object, t) && !strings.HasPrefix(date, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyPrintFilter rebuilds the visible list from the archive using the
+// current filter text and sort order, keeping the highlighted certificate
+// selected if it is still visible.
+func (m *Model) applyPrintFilter() {
+	p := &m.Print
+
+	var selected string
+	if p.Index < len(p.List) {
+		selected = p.List[p.Index].Path
+	}
+
+	q := parseArchiveQuery(p.Filter.Value())
+	list := []CertificateSummary{}
+	for _, s := range p.All {
+		if q.matches(s, m.Cfg.TrackOf(s.Track)) {
+			list = append(list, s)
+		}
+	}
+
+	less := func(a, b CertificateSummary) bool {
+		switch p.Sort {
+		case SORT_BY_SCORE:
+			if a.Score != b.Score {
+				return a.Score > b.Score
+			}
+		case SORT_BY_RANK:
+			ra, rb := m.rankIndex(a), m.rankIndex(b)
+			if ra != rb {
+				return ra > rb
+			}
+		}
+		return a.Date.After(b.Date)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if p.Ascending {
+			return less(list[j], list[i])
+		}
+		return less(list[i], list[j])
+	})
+
+	p.List = list
+	p.Index = 0
+	for i, s := range list {
+		if s.Path == selected {
+			p.Index = i
+			break
+		}
+	}
+}
+
+// rankIndex returns the position of the rank stored on the certificate in
+// the levels of its track. Certificates without a stored rank are ranked by
+// their score.
+func (m *Model) rankIndex(c CertificateSummary) int {
+	levels := m.Cfg.TrackOf(c.Track).SkillLevels
+	if c.Rank == "" {
+		i, _ := skillLevelForScore(levels, c.Score)
+		return i
+	}
+	return levelIndex(levels, c.Rank)
 }
 
 func (m *Model) UpdatePrintModel(msg tea.Msg) []tea.Cmd {
@@ -105,20 +423,64 @@ func (m *Model) UpdatePrintModel(msg tea.Msg) []tea.Cmd {
 		return cmds
 	}
 
+	p := &m.Print
+
+	if p.Filtering {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			switch msg.String() {
+			case "enter":
+				p.Filtering = false
+				p.Filter.Blur()
+				return cmds
+			case "esc":
+				p.Filtering = false
+				p.Filter.Blur()
+				p.Filter.Reset()
+				m.applyPrintFilter()
+				return cmds
+			}
+		}
+
+		before := p.Filter.Value()
+		var cmd tea.Cmd
+		p.Filter, cmd = p.Filter.Update(msg)
+		if p.Filter.Value() != before {
+			m.applyPrintFilter()
+		}
+		return append(cmds, cmd)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc", "q":
 			m.State = STATE_MENU
 			cmds = append(cmds, tea.ClearScreen)
+		case "/":
+			p.Filtering = true
+			cmds = append(cmds, p.Filter.Focus())
+		case "s":
+			p.Sort = (p.Sort + 1) % 3
+			m.applyPrintFilter()
+		case "r":
+			p.Ascending = !p.Ascending
+			m.applyPrintFilter()
 		case "up", "k":
-			if m.PrintIndex > 0 {
-				m.PrintIndex--
+			if p.Index > 0 {
+				p.Index--
 			}
 		case "down", "j":
-			if m.PrintIndex < len(m.PrintList)-1 {
-				m.PrintIndex++
+			if p.Index < len(p.List)-1 {
+				p.Index++
 			}
+		case "left", "h", "pgup":
+			p.Index = max(0, p.Index-printPageSize)
+		case "right", "l", "pgdown":
+			p.Index = max(0, min(len(p.List)-1, p.Index+printPageSize))
+		case "home", "g":
+			p.Index = 0
+		case "end", "G":
+			p.Index = max(0, len(p.List)-1)
 		case "enter":
 			// only trigger generation when the user presses Enter while already
 			// focused in the print view. If we just transitioned into the print
@@ -127,10 +489,10 @@ func (m *Model) UpdatePrintModel(msg tea.Msg) []tea.Cmd {
 			if m.PrevState != STATE_PRINT {
 				break
 			}
-			if len(m.PrintList) == 0 {
+			if len(p.List) == 0 {
 				break
 			}
-			sel := m.PrintList[m.PrintIndex]
+			sel := p.List[p.Index]
 
 			cert, err := loadCertificate(sel.Path)
 			if err != nil {
@@ -140,7 +502,7 @@ func (m *Model) UpdatePrintModel(msg tea.Msg) []tea.Cmd {
 
 			// use the YAML filename (without extension) as the output base name
 			outputBase := strings.TrimSuffix(sel.Name, filepath.Ext(sel.Name))
-			out, err := GenerateCertificatePDF(cert, filepath.Dir(sel.Path), outputBase, m.Cfg.SkillLevels)
+			out, err := GenerateCertificatePDF(cert, filepath.Dir(sel.Path), outputBase, m.Cfg.TrackOf(cert.Track))
 			if err != nil {
 				logger.Printf("Failed to generate certificate PDF/HTML: %v", err)
 				break
@@ -158,11 +520,12 @@ func (m *Model) UpdatePrintModel(msg tea.Msg) []tea.Cmd {
 }
 
 func (m *Model) ViewPrint() (string, string, string) {
-	//s := m.Styles
+	s := m.Styles
+	p := m.Print
 
 	header := "Zertifikat drucken"
 
-	if len(m.PrintList) == 0 {
+	if len(p.All) == 0 {
 		body := "Keine Zertifikate gefunden."
 		footer := m.appBoundaryView("Drücken Sie 'esc' oder 'q' zum Zurückkehren")
 		return header, body, footer
@@ -170,29 +533,106 @@ func (m *Model) ViewPrint() (string, string, string) {
 
 	var b strings.Builder
 
-	fmt.Fprintf(&b, "\n")
+	fmt.Fprintf(&b, "\n%s\n", p.Filter.View())
 
-	for i, s := range m.PrintList {
-		marker := "  "
-		if i == m.PrintIndex {
-			marker = "> "
+	pages := max(1, (len(p.List)+printPageSize-1)/printPageSize)
+	page := p.Index / printPageSize
+	order := "↓"
+	if p.Ascending {
+		order = "↑"
+	}
+	fmt.Fprintf(&b, "%s\n\n", s.Help.Render(fmt.Sprintf("%d/%d · %s %s · Seite %d/%d",
+		len(p.List), len(p.All), p.Sort, order, page+1, pages)))
+
+	if len(p.List) == 0 {
+		b.WriteString("Keine passenden Zertifikate.\n")
+	}
+
+	start := page * printPageSize
+	end := min(start+printPageSize, len(p.List))
+	for i := start; i < end; i++ {
+		c := p.List[i]
+		label := fmt.Sprintf("%s %.2f %s (%s)", c.Date.Format("2006-01-02"), c.Score, c.Applicant, c.ObjectName)
+		if c.Applicant == "" && c.ObjectName == "" {
+			label = fmt.Sprintf("%s %.2f %s", c.Date.Format("2006-01-02"), c.Score, c.Name)
 		}
-		label := s.Name
-		if s.Applicant != "" || s.ObjectName != "" {
-			label = fmt.Sprintf("%s - %s (%s)", s.Date.Format("2006-01-02"), s.Applicant, s.ObjectName)
+		label = truncate(label, printListWidth-2)
+		if i == p.Index {
+			fmt.Fprintf(&b, "> %s\n", s.Highlight.Render(label))
 		} else {
-			label = fmt.Sprintf("%s - %s", s.Date.Format("2006-01-02"), s.Name)
+			fmt.Fprintf(&b, "  %s\n", label)
 		}
-		fmt.Fprintf(&b, "%s%s\n", marker, label)
 	}
 
-	body := lipgloss.JoinVertical(lipgloss.Top, []string{b.String()}...)
+	list := m.Lg.NewStyle().Width(printListWidth).Render(b.String())
 
-	footer := m.appBoundaryView("Navigiere mit Pfeiltasten, Bestätige mit Enter")
+	body := list
+	if len(p.List) > 0 {
+		detail := m.printDetailView(p.List[p.Index])
+		statusMarginLeft := max(1, m.width-printDetailWidth-lipgloss.Width(list)-s.Status.GetMarginRight())
+		status := s.Status.
+			Width(printDetailWidth).
+			MarginLeft(statusMarginLeft).
+			Render(detail)
+		body = lipgloss.JoinHorizontal(lipgloss.Top, list, status)
+	}
+
+	help := "↑/↓ Auswahl · ←/→ Seite · / Filter · s/r Sortierung · Enter Drucken"
+	if p.Filtering {
+		help = "Enter übernehmen · Esc Filter löschen"
+	}
+	footer := m.appBoundaryView(help)
 
 	return header, body, footer
 }
 
+// printDetailView renders the detail pane for the highlighted certificate.
+func (m *Model) printDetailView(c CertificateSummary) string {
+	s := m.Styles
+
+	var b strings.Builder
+	b.WriteString(s.StatusHeader.Render("Zertifikat") + "\n\n")
+	fmt.Fprintf(&b, "%s\n", s.Highlight.Render(c.Applicant))
+	fmt.Fprintf(&b, "%s\n", c.ObjectName)
+	if c.ObjectClass != "" {
+		fmt.Fprintf(&b, "Klasse: %s\n", c.ObjectClass)
+	}
+	track := m.Cfg.TrackOf(c.Track)
+	if len(m.Cfg.Tracks) > 0 {
+		fmt.Fprintf(&b, "Laufbahn: %s\n", track.Title())
+	}
+	fmt.Fprintf(&b, "%s\n\n", c.Date.Format("02.01.2006 15:04"))
+	fmt.Fprintf(&b, "Bewertung: %s\n", s.Highlight.Render(fmt.Sprintf("%.2f", c.Score)))
+	rank := c.Rank
+	if rank == "" {
+		rank = rankForScore(track.SkillLevels, c.Score)
+	}
+	if rank != "" {
+		fmt.Fprintf(&b, "Rang: %s\n", rank)
+	}
+	if status := c.expiryStatus(time.Now()); status != "" {
+		fmt.Fprintf(&b, "%s\n", status)
+	}
+
+	if len(c.Questions) > 0 {
+		b.WriteString("\n")
+		for _, q := range c.Questions {
+			fmt.Fprintf(&b, "%s %.2f\n", truncate(q.Question, printDetailWidth-9), q.Avg)
+		}
+	}
+
+	if len(c.Reviewers) > 0 {
+		b.WriteString("\nBegutachtet durch:\n")
+		for _, r := range c.Reviewers {
+			fmt.Fprintf(&b, "- %s\n", r)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n%s", s.Help.Render(truncate(c.ID(), printDetailWidth-2)))
+
+	return b.String()
+}
+
 // openFile opens the provided path with the OS default handler.
 func openFile(path string) error {
 	var cmd *exec.Cmd
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPrintSortByStoredRank(t *testing.T) {
+	m := newTestModel(t)
+	m.Cfg.SkillLevels = []SkillLevelConfig{
+		{Level: 1, Name: "Lehrling", MinPoints: 0},
+		{Level: 2, Name: "Geselle", MinPoints: 2},
+		{Level: 3, Name: "Meister", MinPoints: 4},
+	}
+	m.InitPrintModel()
+
+	// the stored rank was reached under the thresholds of its ceremony, e.g.
+	// those of an object class, and wins over the score
+	m.Print.All = []CertificateSummary{
+		{Path: "lehrling", Score: 5, Rank: "Lehrling"},
+		{Path: "meister", Score: 1, Rank: "Meister"},
+		{Path: "ohne-rang", Score: 3},
+	}
+	m.Print.Sort = SORT_BY_RANK
+	m.applyPrintFilter()
+
+	var got []string
+	for _, c := range m.Print.List {
+		got = append(got, c.Path)
+	}
+	if want := []string{"meister", "ohne-rang", "lehrling"}; !slices.Equal(got, want) {
+		t.Errorf("sorted %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Answers is the content of an answers file used by the `certify` command to
+// create a certificate without going through the TUI, e.g.
+//
+//	data:
+//	  data_entry:
+//	    applicant_name: Erika Mustermann
+//	    object_description: Schwarzwälder Kirschtorte
+//	    object_class: Torte
+//	    approval: true
+//	reviews:
+//	  - reviewer: Anna
+//	    answers:
+//	      taste:
+//	        rating: 4
+//	        comment: Saftig
+//
+// Values are keyed by group key and field key as configured in config.yaml.
+// Every entry of reviews adds its reviewer to the review panel.
+type Answers struct {
+	Data    map[string]map[string]any `yaml:"data"`
+	Reviews []ReviewAnswers           `yaml:"reviews"`
+}
+
+// ReviewAnswers holds the evaluation values of a single reviewer.
+type ReviewAnswers struct {
+	Reviewer string                    `yaml:"reviewer"`
+	Answers  map[string]map[string]any `yaml:"answers"`
+}
+
+func loadAnswers(path string) (Answers, error) {
+	var answers Answers
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return answers, err
+	}
+
+	if err := yaml.Unmarshal(data, &answers); err != nil {
+		return answers, fmt.Errorf("failed to parse answers file %s: %w", path, err)
+	}
+
+	return answers, nil
+}
+
+// collectAnswers converts the values of an answers file into an answerSheet
+// for the given groups, validating every value like the TUI form would.
+// earlier holds the answers of the forms filled in before, which may be nil.
+func collectAnswers(groups []GroupConfig, values map[string]map[string]any, earlier fieldReader) (answerSheet, error) {
+	sheet := make(answerSheet)
+
+	known := make(map[string]bool)
+	for _, g := range groups {
+		for _, fc := range g.Fields {
+			known[BuildFieldKey(g.Key, fc.Key)] = true
+
+			v, err := normalizeAnswer(fc, values[g.Key][fc.Key])
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", g.Key, fc.Key, err)
+			}
+			sheet[BuildFieldKey(g.Key, fc.Key)] = v
+		}
+	}
+
+	// values of hidden fields are dropped like in the TUI; validate once
+	// all values are known, rules may depend on other fields
+	dropHidden(groups, sheet, earlier)
+	form := layeredReader{sheet, earlier}
+	for _, g := range groups {
+		for _, fc := range g.Fields {
+			if !g.shown(fc, form) {
+				continue
+			}
+			if err := validateAnswer(fc, g.Key, form, sheet[BuildFieldKey(g.Key, fc.Key)]); err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", g.Key, fc.Key, err)
+			}
+		}
+	}
+
+	// reject values that do not belong to any configured field, these are
+	// most likely typos which would otherwise silently be dropped
+	for groupKey, fields := range values {
+		for fieldKey := range fields {
+			if !known[BuildFieldKey(groupKey, fieldKey)] {
+				return nil, fmt.Errorf("%s.%s: unknown field", groupKey, fieldKey)
+			}
+		}
+	}
+
+	return sheet, nil
+}
+
+// checkRecertification checks that a ceremony of the applicant on the
+// track of cfg, as returned by ForCeremony, may renew the certificate.
+func checkRecertification(cfg Configuration, renewed CertificateSummary, applicant string) error {
+	if !strings.EqualFold(strings.TrimSpace(renewed.Applicant), strings.TrimSpace(applicant)) {
+		return fmt.Errorf("recertify: certificate %s belongs to %q, not %q", renewed.ID(), renewed.Applicant, applicant)
+	}
+	if len(cfg.Tracks) > 0 && !cfg.onTrack(renewed.Track) {
+		return fmt.Errorf("recertify: certificate %s is on track %q, not %q",
+			renewed.ID(), cfg.TrackOf(renewed.Track).Title(), cfg.TrackOf(cfg.track).Title())
+	}
+	return nil
+}
+
+// runCertify implements the `certify` command: it creates a certificate from
+// an answers file the same way a ceremony in the TUI would.
+func runCertify(cfg Configuration, args []string) error {
+
+	flags := flag.NewFlagSet("certify", flag.ContinueOnError)
+	answersPath := flags.String("answers", "", "path to the answers file (YAML)")
+	recertify := flags.String("recertify", "", "id of the certificate renewed by the new one")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *answersPath == "" {
+		return fmt.Errorf("certify: missing --answers <file>")
+	}
+
+	var renewed CertificateSummary
+	if *recertify != "" {
+		var err error
+		if renewed, err = findCertificate(*recertify); err != nil {
+			return err
+		}
+	}
+
+	answers, err := loadAnswers(*answersPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := collectAnswers(cfg.DataCollection, answers.Data, nil)
+	if err != nil {
+		return fmt.Errorf("data collection: %w", err)
+	}
+	// the track decides the levels, the class of the object the evaluation
+	class := strings.TrimSpace(data.GetString(cfg.DataKey(ROLE_CLASS)))
+	cfg = cfg.ForCeremony(data.GetString(cfg.DataKey(ROLE_TRACK)), class)
+	if *recertify != "" {
+		if err := checkRecertification(cfg, renewed, data.GetString(cfg.DataKey(ROLE_APPLICANT))); err != nil {
+			return err
+		}
+	}
+
+	// the panel consists of the reviewers of the answers file in their
+	// order; names entered in legacy reviewer fields must all have a review
+	reviewerNames := make(map[int]string)
+	sheets := make(map[int]fieldReader)
+	seen := make(map[string]bool)
+	for i, r := range answers.Reviews {
+		name := strings.TrimSpace(r.Reviewer)
+		if name == "" {
+			return fmt.Errorf("reviews: review %d has no reviewer", i+1)
+		}
+		if seen[strings.ToLower(name)] {
+			return fmt.Errorf("reviews: more than one review by %q", name)
+		}
+		seen[strings.ToLower(name)] = true
+
+		sheet, err := collectAnswers(cfg.Evaluation, r.Answers, data)
+		if err != nil {
+			return fmt.Errorf("review by %s: %w", name, err)
+		}
+
+		reviewerNames[i+1] = name
+		sheets[i+1] = layeredReader{sheet, data}
+	}
+
+	for _, key := range reviewerFields(cfg.DataCollection) {
+		name := strings.TrimSpace(data.GetString(key))
+		if name != "" && !seen[strings.ToLower(name)] {
+			return fmt.Errorf("reviews: missing review by %q", name)
+		}
+	}
+
+	if len(sheets) < cfg.ReviewPanel.MinSize() {
+		return fmt.Errorf("reviews: at least %d reviews required, got %d", cfg.ReviewPanel.MinSize(), len(sheets))
+	}
+	if cfg.ReviewPanel.Max > 0 && len(sheets) > cfg.ReviewPanel.Max {
+		return fmt.Errorf("reviews: at most %d reviewers allowed, got %d", cfg.ReviewPanel.Max, len(sheets))
+	}
+
+	certificate := buildCertificate(
+		cfg,
+		data.GetString(cfg.DataKey(ROLE_APPLICANT)),
+		data.GetString(cfg.DataKey(ROLE_OBJECT)),
+		resolveReviewers(reviewerNames),
+		sheets,
+	)
+	certificate.Recertifies = renewed.ID()
+	certificate.ObjectClass = class
+
+	certificatePath, err := storeCertificate(certificate, data.GetString(cfg.DataKey(ROLE_IMAGE)), cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Zertifikat %s erstellt: %s\n", certificate.ID, certificatePath)
+	fmt.Printf("Bewertung: %.2f, Rang: %s\n", certificate.Score.Total, certificate.Score.Level)
+	if next := certificate.Score.NextLevel(); next != nil {
+		fmt.Printf("%s nicht erreicht: %s\n", next.Name, strings.Join(next.Blockers, "; "))
+	}
+	if certificate.ValidUntil != nil {
+		fmt.Printf("Gültig bis: %s\n", certificate.ValidUntil.Format("2006-01-02"))
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestCheckRecertification(t *testing.T) {
+	levels := []SkillLevelConfig{{Level: 1, Name: "Geselle"}}
+	tracks := Configuration{Tracks: []TrackConfig{
+		{Key: "backen", Name: "Backen", SkillLevels: levels},
+		{Key: "dekor", Name: "Dekoration", SkillLevels: levels},
+	}}
+	renewed := CertificateSummary{Name: "abc.yaml", Applicant: "Erika Mustermann", Track: "backen"}
+
+	for _, tc := range []struct {
+		name      string
+		cfg       Configuration
+		applicant string
+		ok        bool
+	}{
+		{"same applicant", Configuration{SkillLevels: levels}, " erika mustermann", true},
+		{"other applicant", Configuration{SkillLevels: levels}, "Max Mustermann", false},
+		{"same track", tracks.ForCeremony("Backen", ""), "Erika Mustermann", true},
+		{"other track", tracks.ForCeremony("dekor", ""), "Erika Mustermann", false},
+		{"other applicant on the same track", tracks.ForCeremony("backen", ""), "Max Mustermann", false},
+	} {
+		err := checkRecertification(tc.cfg, renewed, tc.applicant)
+		if ok := err == nil; ok != tc.ok {
+			t.Errorf("%s: got error %v", tc.name, err)
+		}
+	}
+}
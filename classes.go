@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ClassConfig adapts the evaluation to objects of one class, e.g.
+//
+//	classes:
+//	- class: Torte
+//	  weights:
+//	    taste: 2
+//	  skilllevels:
+//	  - level: 3
+//	    min_points: 3.5
+//
+// Objects of classes without an entry are evaluated as configured at the
+// top level.
+type ClassConfig struct {
+	// Class is the answer to the class field the entry applies to.
+	Class string `yaml:"class"`
+	// Evaluation replaces the evaluation groups if given.
+	Evaluation []GroupConfig `yaml:"evaluation,omitempty"`
+	// Weights replaces the weights of the score fields, keyed by group.
+	Weights map[string]float32 `yaml:"weights,omitempty"`
+	// SkillLevels override the thresholds of the skill levels with the
+	// same number.
+	SkillLevels []LevelThresholds `yaml:"skilllevels,omitempty"`
+
+	pos position
+}
+
+// LevelThresholds override the thresholds of a skill level, omitted ones
+// are kept. With tracks they apply to the level of every track unless Track
+// names one.
+type LevelThresholds struct {
+	Track          string             `yaml:"track,omitempty"`
+	Level          int                `yaml:"level"`
+	MinPoints      *float32           `yaml:"min_points,omitempty"`
+	MinGroupScores map[string]float32 `yaml:"min_group_scores,omitempty"`
+	MinRating      *float64           `yaml:"min_rating,omitempty"`
+	MinReviewers   *int               `yaml:"min_reviewers,omitempty"`
+}
+
+// class returns the entry of the given object class, nil if it has none.
+func (c Configuration) class(class string) *ClassConfig {
+	class = strings.TrimSpace(class)
+	for i := range c.Classes {
+		if class != "" && strings.EqualFold(c.Classes[i].Class, class) {
+			return &c.Classes[i]
+		}
+	}
+	return nil
+}
+
+// ForClass returns the configuration evaluating objects of the given class:
+// the evaluation groups, weights and skill level thresholds of its entry in
+// Classes replace the configured ones.
+func (c Configuration) ForClass(class string) Configuration {
+	cc := c.class(class)
+	if cc == nil {
+		return c
+	}
+
+	res := c
+	res.Classes = nil
+	if len(cc.Evaluation) > 0 {
+		res.Evaluation = cc.Evaluation
+	}
+
+	if len(cc.Weights) > 0 {
+		groups := make([]GroupConfig, len(res.Evaluation))
+		for i, g := range res.Evaluation {
+			g.Fields = slices.Clone(g.Fields)
+			for j, fc := range g.Fields {
+				if w, ok := cc.Weights[g.Key]; ok && fc.Role == ROLE_SCORE {
+					g.Fields[j].Weight = w
+				}
+			}
+			groups[i] = g
+		}
+		res.Evaluation = groups
+	}
+
+	if len(cc.SkillLevels) > 0 {
+		res.SkillLevels = slices.Clone(c.SkillLevels)
+		for _, t := range cc.SkillLevels {
+			if t.Track != "" && !strings.EqualFold(t.Track, res.track) {
+				continue
+			}
+			for i, l := range res.SkillLevels {
+				if l.Level != t.Level {
+					continue
+				}
+				if t.MinPoints != nil {
+					l.MinPoints = *t.MinPoints
+				}
+				if t.MinGroupScores != nil {
+					l.MinGroupScores = t.MinGroupScores
+				}
+				if t.MinRating != nil {
+					l.MinRating = *t.MinRating
+				}
+				if t.MinReviewers != nil {
+					l.MinReviewers = *t.MinReviewers
+				}
+				res.SkillLevels[i] = l
+			}
+		}
+	}
+
+	return res
+}
+
+// validateClasses checks the class entries and the configuration each of
+// them results in.
+func (c Configuration) validateClasses() error {
+	if len(c.Classes) == 0 {
+		return nil
+	}
+
+	if c.DataKey(ROLE_CLASS) == "" {
+		return fmt.Errorf("classes: the data collection has no field with role %s", ROLE_CLASS)
+	}
+	// classes are checked against the options of a select
+	var options []string
+	for _, g := range c.DataCollection {
+		for _, fc := range g.Fields {
+			if fc.Role == ROLE_CLASS {
+				options = fc.Options
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, cc := range c.Classes {
+		if err := c.validateClass(cc, options, seen); err != nil {
+			return cc.pos.wrap(err)
+		}
+	}
+	return nil
+}
+
+// validateClass checks a single class entry, seen holds the classes of the
+// entries before it.
+func (c Configuration) validateClass(cc ClassConfig, options []string, seen map[string]bool) error {
+	where := fmt.Sprintf("classes %q", cc.Class)
+	if strings.TrimSpace(cc.Class) == "" {
+		return fmt.Errorf("classes: entry without class")
+	}
+	if seen[strings.ToLower(cc.Class)] {
+		return fmt.Errorf("%s: defined more than once", where)
+	}
+	seen[strings.ToLower(cc.Class)] = true
+	if len(options) > 0 && !slices.ContainsFunc(options, func(o string) bool { return strings.EqualFold(o, cc.Class) }) {
+		return fmt.Errorf("%s: not one of %s", where, strings.Join(options, ", "))
+	}
+
+	scored := scoredGroupKeys(c.ForClass(cc.Class).Evaluation)
+	for key, w := range cc.Weights {
+		if !slices.Contains(scored, key) {
+			return fmt.Errorf("%s: weights: %q is no evaluation group with a score field", where, key)
+		}
+		if w < 0 {
+			return fmt.Errorf("%s: weights: %q must not be negative", where, key)
+		}
+	}
+	for _, t := range cc.SkillLevels {
+		tracks := c.tracks()
+		if t.Track != "" {
+			track, ok := c.findTrack(t.Track)
+			if !ok || !strings.EqualFold(track.Key, t.Track) {
+				return fmt.Errorf("%s: skilllevels: there is no track %q", where, t.Track)
+			}
+			tracks = []TrackConfig{track}
+		}
+		if !slices.ContainsFunc(tracks, func(track TrackConfig) bool {
+			return slices.ContainsFunc(track.SkillLevels, func(l SkillLevelConfig) bool { return l.Level == t.Level })
+		}) {
+			return fmt.Errorf("%s: skilllevels: there is no level %d", where, t.Level)
+		}
+	}
+	for _, track := range c.tracks() {
+		if err := c.ForCeremony(track.Key, cc.Class).validate(); err != nil {
+			if len(c.Tracks) > 0 {
+				return fmt.Errorf("%s: tracks %q: %w", where, track.Title(), err)
+			}
+			return fmt.Errorf("%s: %w", where, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+)
+
+const commandUsage = `usage: ceremonymaster [command] [flags]
+
+Without a command the interactive ceremony is started.
+
+Commands:
+  certify --answers <file>   create a certificate from an answers file,
+          [--recertify <id>] renewing the given certificate
+  list [filters]             list certificates, newest first
+  show <id> [--format f]     print a certificate as table or yaml
+  render <id> [--out dir]    render a certificate to HTML/PDF
+  export [filters]           export certificates as csv, json or yaml
+  index [rebuild]            verify or rebuild the certificate index
+  expiring [--days n]        list expired certificates and those expiring
+          [--track t]        within n days (default 30)
+  roster list [--all]        list the known reviewers
+  roster import <file.csv>   add or update reviewers from CSV
+  config validate [file]     check the configuration, by default the one
+                             in the application directory
+
+Filters (list, export):
+  --from YYYY-MM-DD  --to YYYY-MM-DD  --applicant text  --object text
+  --reviewer name    (matches the roster entry, also under former names)
+  --track key        (key or name of a track)
+
+Certificate ids may be abbreviated to any unique prefix.`
+
+// needsConfiguration reports whether the command requires a valid
+// configuration.
+func needsConfiguration(command string) bool {
+	switch command {
+	case "config", "help", "-h", "--help":
+		return false
+	}
+	return true
+}
+
+// runCommand dispatches the non-interactive commands given on the command
+// line.
+func runCommand(cfg Configuration, args []string) error {
+	switch args[0] {
+	case "certify":
+		return runCertify(cfg, args[1:])
+	case "list":
+		return runList(cfg, args[1:])
+	case "show":
+		return runShow(cfg, args[1:])
+	case "render":
+		return runRender(cfg, args[1:])
+	case "export":
+		return runExport(cfg, args[1:])
+	case "index":
+		return runIndex(args[1:])
+	case "expiring":
+		return runExpiring(cfg, args[1:])
+	case "roster":
+		return runRoster(args[1:])
+	case "config":
+		return runConfig(args[1:])
+	case "help", "-h", "--help":
+		fmt.Println(commandUsage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], commandUsage)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldCondition holds when the value of another field equals the given
+// value or lies within min and max. Without any of these it holds when the
+// field is filled in.
+type FieldCondition struct {
+	// Field is the key of a field of the same group or "group.field" for
+	// a field of another group of the same form.
+	Field  string   `yaml:"field"`
+	Equals string   `yaml:"equals,omitempty"`
+	Min    *float64 `yaml:"min,omitempty"`
+	Max    *float64 `yaml:"max,omitempty"`
+}
+
+// key returns the field key of the field the condition refers to.
+func (c FieldCondition) key(groupKey string) string {
+	if group, field, ok := strings.Cut(c.Field, "."); ok {
+		return BuildFieldKey(group, field)
+	}
+	return BuildFieldKey(groupKey, c.Field)
+}
+
+// holds reports whether the condition holds for the given value of the
+// referenced field.
+func (c FieldCondition) holds(v any) bool {
+	var values []string
+	switch t := v.(type) {
+	case nil:
+	case []string:
+		values = t
+	case bool:
+		if t {
+			values = []string{"true"}
+		}
+	default:
+		if s := strings.TrimSpace(fmt.Sprint(t)); s != "" {
+			values = []string{s}
+		}
+	}
+
+	if c.Equals == "" && c.Min == nil && c.Max == nil {
+		return len(values) > 0
+	}
+	for _, s := range values {
+		if c.Equals != "" && s != c.Equals {
+			continue
+		}
+		if c.Min != nil || c.Max != nil {
+			n, err := parseNumber(s)
+			if err != nil || (c.Min != nil && n < *c.Min) || (c.Max != nil && n > *c.Max) {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Conditions hold when any of its conditions holds. In the configuration a
+// single condition may be given without the list, e.g.
+//
+//	show_if:
+//	  field: data_entry.object_class
+//	  equals: Torte
+type Conditions []FieldCondition
+
+func (cs *Conditions) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		var c FieldCondition
+		if err := node.Decode(&c); err != nil {
+			return err
+		}
+		*cs = Conditions{c}
+		return nil
+	}
+	var list []FieldCondition
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*cs = list
+	return nil
+}
+
+// hold reports whether any of the conditions holds for the values of form,
+// keys without group refer to groupKey. No conditions always hold.
+func (cs Conditions) hold(groupKey string, form fieldReader) bool {
+	if len(cs) == 0 {
+		return true
+	}
+	for _, c := range cs {
+		if c.holds(form.Get(c.key(groupKey))) {
+			return true
+		}
+	}
+	return false
+}
+
+// validate checks that the conditions refer to the given field keys only.
+func (cs Conditions) validate(groupKey string, keys []string) error {
+	for _, c := range cs {
+		if c.Field == "" {
+			return fmt.Errorf("condition without field")
+		}
+		if !slices.Contains(keys, c.key(groupKey)) {
+			return fmt.Errorf("condition refers to unknown field %q", c.Field)
+		}
+		if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+			return fmt.Errorf("condition min %s exceeds max %s", formatRating(*c.Min), formatRating(*c.Max))
+		}
+	}
+	return nil
+}
+
+// layeredReader reads the values of a form and falls back to the forms
+// filled in before it, e.g. the data collection for the evaluation.
+type layeredReader []fieldReader
+
+func (l layeredReader) Get(key string) any {
+	for _, r := range l {
+		if r == nil {
+			continue
+		}
+		if v := r.Get(key); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+func (l layeredReader) GetString(key string) string {
+	s, _ := l.Get(key).(string)
+	return s
+}
+
+// withEarlier returns the sheets reading the values of earlier, the data
+// collection, as well, so the conditions of the evaluation groups can be
+// evaluated on them.
+func withEarlier(sheets map[int]fieldReader, earlier fieldReader) map[int]fieldReader {
+	res := make(map[int]fieldReader, len(sheets))
+	for idx, sheet := range sheets {
+		res[idx] = layeredReader{sheet, earlier}
+	}
+	return res
+}
+
+// shown reports whether the field is shown given the values of form.
+func (g GroupConfig) shown(fc FieldConfig, form fieldReader) bool {
+	return g.ShowIf.hold(g.Key, form) && fc.ShowIf.hold(g.Key, form)
+}
+
+// dropHidden removes the values of hidden fields and groups from sheet, so
+// they don't count. Fields are visited in order, so a field depending on a
+// hidden one is evaluated without its value.
+func dropHidden(groups []GroupConfig, sheet answerSheet, earlier fieldReader) {
+	form := layeredReader{sheet, earlier}
+	for _, g := range groups {
+		for _, fc := range g.Fields {
+			if !g.shown(fc, form) {
+				delete(sheet, BuildFieldKey(g.Key, fc.Key))
+			}
+		}
+	}
+}
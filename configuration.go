@@ -3,22 +3,91 @@ package main
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Configuration struct {
-	DataPath       string             `yaml:"data_path,omitempty"`
-	DataCollection []GroupConfig      `yaml:"datacollection"`
-	Evaluation     []GroupConfig      `yaml:"evaluation"`
-	SkillLevels    []SkillLevelConfig `yaml:"skilllevels"`
+	DataPath       string            `yaml:"data_path,omitempty"`
+	DataCollection []GroupConfig     `yaml:"datacollection"`
+	ReviewPanel    ReviewPanelConfig `yaml:"review_panel"`
+	Evaluation     []GroupConfig     `yaml:"evaluation"`
+	// Aggregation combines the group results into the total score, one of
+	// the AGGREGATION_* strategies; empty is the weighted mean.
+	Aggregation string `yaml:"aggregation,omitempty"`
+	// Formula computes the total score from the group results instead of
+	// Aggregation, see formula.go.
+	Formula string `yaml:"formula,omitempty"`
+	// Scale is the scale of the total score and the skill level thresholds.
+	// If omitted it is the scale shared by all score fields, or 0 to 5 if
+	// they differ.
+	Scale       *RatingScale       `yaml:"scale,omitempty"`
+	SkillLevels []SkillLevelConfig `yaml:"skilllevels"`
+	// Classes adapt the evaluation to the class of the object, see
+	// ClassConfig.
+	Classes []ClassConfig `yaml:"classes,omitempty"`
+	// Tracks replace SkillLevels by several independent ladders of levels,
+	// see TrackConfig.
+	Tracks []TrackConfig `yaml:"tracks,omitempty"`
+
+	// track is the key of the track chosen by ForCeremony.
+	track string
+	// total is Formula as parsed by parseConfiguration, formulaPos its
+	// position in the file.
+	total      formula
+	formulaPos position
+}
+
+// ReviewPanelConfig limits the number of reviewers of a ceremony. Min is the
+// number of completed reviews required for a certificate (at least one), a
+// Max of 0 allows any number of reviewers.
+type ReviewPanelConfig struct {
+	Min int `yaml:"min"`
+	Max int `yaml:"max"`
+}
+
+func (c ReviewPanelConfig) MinSize() int {
+	return max(1, c.Min)
+}
+
+// Full reports whether a panel of the given size can take no more reviewers.
+func (c ReviewPanelConfig) Full(size int) bool {
+	return c.Max > 0 && size >= c.Max
 }
 
+// SkillLevelConfig describes a skill level and the rules for reaching it.
+// Besides MinPoints the rules are optional, see checkLevels.
 type SkillLevelConfig struct {
 	Level       int     `yaml:"level"`
 	Name        string  `yaml:"name"`
 	Description string  `yaml:"description"`
 	MinPoints   float32 `yaml:"min_points"`
+	// MinGroupScores requires a minimum result per evaluation group key.
+	MinGroupScores map[string]float32 `yaml:"min_group_scores,omitempty"`
+	// MinRating requires every single rating to be at least this value.
+	MinRating float64 `yaml:"min_rating,omitempty"`
+	// MinReviewers requires a minimum number of completed reviews.
+	MinReviewers int `yaml:"min_reviewers,omitempty"`
+	// RequiresLevel requires the applicant to hold the level with this
+	// number from an earlier certificate.
+	RequiresLevel *int `yaml:"requires_level,omitempty"`
+	// Progression requires the level to be earned over several ceremonies.
+	Progression *ProgressionRule `yaml:"progression,omitempty"`
+	// ValidMonths limits the validity of certificates granting the level,
+	// 0 is unlimited.
+	ValidMonths int `yaml:"valid_months,omitempty"`
+
+	pos position
+}
+
+// ProgressionRule grants a level only after the applicant met its rules in
+// Certificates ceremonies within WithinMonths months, the current one
+// included. WithinMonths 0 counts all ceremonies.
+type ProgressionRule struct {
+	Certificates int `yaml:"certificates"`
+	WithinMonths int `yaml:"within_months,omitempty"`
 }
 
 type GroupConfig struct {
@@ -26,22 +95,73 @@ type GroupConfig struct {
 	Title       string        `yaml:"name"`
 	Description string        `yaml:"description"`
 	Fields      []FieldConfig `yaml:"fields"`
+	// Aggregation combines the ratings of an evaluation group, one of the
+	// AGGREGATION_* strategies; empty is the mean.
+	Aggregation string `yaml:"aggregation,omitempty"`
+	// ShowIf asks the group only if one of the conditions holds for the
+	// earlier answers.
+	ShowIf Conditions `yaml:"show_if,omitempty"`
+
+	pos position
+}
+
+// Field roles tell what a field means to the application independent of
+// its key.
+const (
+	ROLE_APPLICANT = "applicant" // name of the applicant
+	ROLE_OBJECT    = "object"    // the object to certify
+	ROLE_CLASS     = "class"     // the class of the object
+	ROLE_TRACK     = "track"     // the track of the ceremony
+	ROLE_IMAGE     = "image"     // picture of the object
+	ROLE_REVIEWER  = "reviewer"  // name of a reviewer (fixed panels)
+	ROLE_SCORE     = "score"     // rating of an evaluation group
+	ROLE_COMMENT   = "comment"   // comment on an evaluation group
+)
+
+// fieldTypes lists the supported field types.
+var fieldTypes = map[string]bool{
+	"range":       true, // rating on a scale
+	"input":       true, // single line of text
+	"text":        true, // multiple lines of text
+	"select":      true, // one of the options
+	"multiselect": true, // any of the options
+	"filepicker":  true, // path of a file
+	"confirm":     true, // yes or no
+	"number":      true, // a number between min and max
+	"date":        true, // a date, YYYY-MM-DD or DD.MM.YYYY
+	"ranking":     true, // the options in order of preference
+	"likert":      true, // each of the statements rated on one scale
 }
 
 type FieldConfig struct {
-	Type        string   `yaml:"type"` // "select" or "range" or "input" or "text" or "filepicker" or "confirm"
-	Key         string   `yaml:"key"`  // *MUST BE* <type>_<identifier>
+	Type        string   `yaml:"type"` // one of fieldTypes
+	Key         string   `yaml:"key"`
+	Role        string   `yaml:"role,omitempty"` // one of the ROLE_* constants
 	Title       string   `yaml:"title"`
 	Description string   `yaml:"description,omitempty"`
 	Mandatory   bool     `yaml:"mandatory"`
-	Options     []string `yaml:"options,omitempty"`     // for select
+	Options     []string `yaml:"options,omitempty"`     // for select, multiselect and ranking
 	Affirmative string   `yaml:"affirmative,omitempty"` // for confirm
 	Negative    string   `yaml:"negative,omitempty"`    // for confirm
 	RequireYes  bool     `yaml:"require_yes,omitempty"` // if true, confirm validation fails when false
-	// Weight is an optional multiplier used when summarizing numeric
-	// evaluation fields across reviewers. If omitted, a weight of 1.0
-	// is assumed.
+	// Weight is the weight of the group of a score field in the total
+	// score. If omitted, a weight of 1.0 is assumed.
 	Weight float32 `yaml:"weight,omitempty"` // for range
+	// Scale defines the values of a range field, 0 to 5 stars if omitted,
+	// or of the statements of a likert field, 1 to 5 if omitted.
+	Scale *RatingScale `yaml:"scale,omitempty"` // for range and likert
+	// Min and Max limit the value of a number field, both are optional.
+	Min *float64 `yaml:"min,omitempty"` // for number
+	Max *float64 `yaml:"max,omitempty"` // for number
+	// Statements are rated one by one on the scale of a likert field.
+	Statements []string `yaml:"statements,omitempty"` // for likert
+	// Rules further restrict the value, see ValidationRule.
+	Rules []ValidationRule `yaml:"rules,omitempty"`
+	// ShowIf asks the field only if one of the conditions holds for the
+	// earlier answers.
+	ShowIf Conditions `yaml:"show_if,omitempty"`
+
+	pos position
 }
 
 func defaultConfiguration() Configuration {
@@ -78,7 +198,7 @@ func defaultConfiguration() Configuration {
 				MinPoints:   4.0,
 			},
 			{
-				Level:       4,
+				Level:       5,
 				Name:        "Principal Cake Architect 🤯",
 				Description: "",
 				MinPoints:   4.6,
@@ -93,6 +213,7 @@ func defaultConfiguration() Configuration {
 					{
 						Type:        "input",
 						Key:         "applicant_name",
+						Role:        ROLE_APPLICANT,
 						Title:       "Name des Antragstellers",
 						Description: "Bitte geben Sie den vollständigen Namen des Antragstellers ein.",
 						Mandatory:   true,
@@ -100,6 +221,7 @@ func defaultConfiguration() Configuration {
 					{
 						Type:        "text",
 						Key:         "object_description",
+						Role:        ROLE_OBJECT,
 						Title:       "Zertifizierungsobjekt",
 						Description: "Was soll zertifiziert werden?",
 						Mandatory:   true,
@@ -107,6 +229,7 @@ func defaultConfiguration() Configuration {
 					{
 						Type:        "select",
 						Key:         "object_class",
+						Role:        ROLE_CLASS,
 						Title:       "Zertifizierungsobjektklasse",
 						Description: "Welcher Art ist das Objekt?",
 						Options:     []string{"Kuchen", "Torte", "Sonstiges"},
@@ -115,6 +238,7 @@ func defaultConfiguration() Configuration {
 					{
 						Type:        "filepicker",
 						Key:         "object_image",
+						Role:        ROLE_IMAGE,
 						Title:       "Zertifizierungsobjektbild",
 						Description: "Bitte wählen Sie ein Bild des Zertifizierungsobjekts aus.",
 						Options:     []string{".png", ".jpg"},
@@ -131,46 +255,10 @@ func defaultConfiguration() Configuration {
 					},
 				},
 			},
-			{
-				Key:         "reviewer",
-				Title:       "Review Panel",
-				Description: "Wer wird den Zertifzierungsantrag prüfen?",
-				Fields: []FieldConfig{
-					{
-						Type:        "input",
-						Key:         "1",
-						Title:       "Zertifizierer (1)",
-						Description: "Gib einen Namen des Zertifizierers ein.",
-					},
-					{
-						Type:        "input",
-						Key:         "2",
-						Title:       "Zertifizierer (2)",
-						Description: "Gib einen Namen des Zertifizierers ein.",
-					},
-					{
-						Type:        "input",
-						Key:         "3",
-						Title:       "Zertifizierer (3)",
-						Description: "Gib einen Namen des Zertifizierers ein.",
-					},
-					{
-						Type:        "input",
-						Key:         "4",
-						Title:       "Zertifizierer (4)",
-						Description: "Gib einen Namen des Zertifizierers ein.",
-					},
-					{
-						Type:        "confirm",
-						Key:         "approval",
-						Title:       "Zertifizierer erfasst?",
-						Description: "Sind alle Zertifizierenden erfasst?",
-						Affirmative: "Ja!",
-						Negative:    "Oh nö...",
-						RequireYes:  true,
-					},
-				},
-			},
+		},
+		ReviewPanel: ReviewPanelConfig{
+			Min: 1,
+			Max: 8,
 		},
 		Evaluation: []GroupConfig{
 			{
@@ -181,6 +269,7 @@ func defaultConfiguration() Configuration {
 					{
 						Type:        "range",
 						Key:         "rating",
+						Role:        ROLE_SCORE,
 						Title:       "Aussehen",
 						Description: "Wie sieht das Zertifizierungsobjekt aus?",
 						Mandatory:   true,
@@ -189,6 +278,7 @@ func defaultConfiguration() Configuration {
 					{
 						Type:        "text",
 						Key:         "comment",
+						Role:        ROLE_COMMENT,
 						Title:       "Kommentar",
 						Description: "Was sind deine Gedanken dazu?",
 						Mandatory:   false,
@@ -203,6 +293,7 @@ func defaultConfiguration() Configuration {
 					{
 						Type:        "range",
 						Key:         "rating",
+						Role:        ROLE_SCORE,
 						Title:       "Geruch",
 						Description: "Wie gut riecht das Zertifizierungsobjekt?",
 						Mandatory:   true,
@@ -211,6 +302,7 @@ func defaultConfiguration() Configuration {
 					{
 						Type:        "text",
 						Key:         "comment",
+						Role:        ROLE_COMMENT,
 						Title:       "Kommentar",
 						Description: "Was sind deine Gedanken dazu?",
 						Mandatory:   false,
@@ -225,6 +317,7 @@ func defaultConfiguration() Configuration {
 					{
 						Type:        "range",
 						Key:         "rating",
+						Role:        ROLE_SCORE,
 						Title:       "Geschmack",
 						Description: "Wie gut schmeckt das Zertifizierungsobjekt?",
 						Mandatory:   true,
@@ -233,6 +326,7 @@ func defaultConfiguration() Configuration {
 					{
 						Type:        "text",
 						Key:         "comment",
+						Role:        ROLE_COMMENT,
 						Title:       "Kommentar",
 						Description: "Was sind deine Gedanken dazu?",
 						Mandatory:   false,
@@ -247,6 +341,7 @@ func defaultConfiguration() Configuration {
 					{
 						Type:        "range",
 						Key:         "rating",
+						Role:        ROLE_SCORE,
 						Title:       "Innovationsgrad",
 						Description: "Wie innovativ ist das Zertifizierungsobjekt?",
 						Mandatory:   true,
@@ -255,6 +350,7 @@ func defaultConfiguration() Configuration {
 					{
 						Type:        "text",
 						Key:         "comment",
+						Role:        ROLE_COMMENT,
 						Title:       "Kommentar",
 						Description: "Was sind deine Gedanken dazu?",
 						Mandatory:   false,
@@ -269,6 +365,7 @@ func defaultConfiguration() Configuration {
 					{
 						Type:        "range",
 						Key:         "rating",
+						Role:        ROLE_SCORE,
 						Title:       "Komplexitätsgrad",
 						Description: "Wie komplex ist das Zertifizierungsobjekt?",
 						Mandatory:   true,
@@ -277,6 +374,7 @@ func defaultConfiguration() Configuration {
 					{
 						Type:        "text",
 						Key:         "comment",
+						Role:        ROLE_COMMENT,
 						Title:       "Kommentar",
 						Description: "Was sind deine Gedanken dazu?",
 						Mandatory:   false,
@@ -291,6 +389,7 @@ func defaultConfiguration() Configuration {
 					{
 						Type:        "range",
 						Key:         "rating",
+						Role:        ROLE_SCORE,
 						Title:       "Präsentationsformat",
 						Description: "Wie wurde das Zertifizierungsobjekt präsentiert?",
 						Mandatory:   true,
@@ -299,6 +398,7 @@ func defaultConfiguration() Configuration {
 					{
 						Type:        "text",
 						Key:         "comment",
+						Role:        ROLE_COMMENT,
 						Title:       "Kommentar",
 						Description: "Was sind deine Gedanken dazu?",
 						Mandatory:   false,
@@ -312,28 +412,326 @@ func defaultConfiguration() Configuration {
 /** Load configuration from YAML file */
 func loadConfiguration(path string) (Configuration, error) {
 
-	if _, err := os.Stat(path); err != nil {
-		logger.Println("Configuration file not found, using default configuration.")
-		return defaultConfiguration(), err
-	}
-
 	data, err := os.ReadFile(path)
 	if err != nil {
-		logger.Println("Failed to read configuration file, using default configuration:", err)
-		return defaultConfiguration(), err
+		logger.Println("Failed to read configuration file:", err)
+		return Configuration{}, err
 	}
 
-	fmt.Println("Configuration file read: ", path)
+	logger.Println("Configuration file read: ", path)
 
-	var configuration Configuration
-	if err := yaml.Unmarshal(data, &configuration); err != nil {
-		logger.Println("error unmarshaling configuration from YAML, using default configuration:", err)
-		return defaultConfiguration(), err
+	configuration, err := parseConfiguration(data)
+	if err != nil {
+		err = formatConfigErrors(path, err)
+		logger.Printf("Invalid configuration:\n%v", err)
+		return configuration, err
 	}
 
 	return configuration, nil
 }
 
+// DataKey returns the field key of the data collection field with the
+// given role, or "" if there is none.
+func (c Configuration) DataKey(role string) string {
+	for _, g := range c.DataCollection {
+		for _, fc := range g.Fields {
+			if fc.Role == role {
+				return BuildFieldKey(g.Key, fc.Key)
+			}
+		}
+	}
+	return ""
+}
+
+// FieldKey returns the field key of the group's field with the given role,
+// or "" if there is none.
+func (g GroupConfig) FieldKey(role string) string {
+	for _, fc := range g.Fields {
+		if fc.Role == role {
+			return BuildFieldKey(g.Key, fc.Key)
+		}
+	}
+	return ""
+}
+
+func (c Configuration) hasRoles() bool {
+	for _, groups := range [][]GroupConfig{c.DataCollection, c.Evaluation} {
+		for _, g := range groups {
+			for _, fc := range g.Fields {
+				if fc.Role != "" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// applyLegacyRoles assigns roles to configurations written before roles
+// existed, using the key conventions those versions relied on.
+func (c *Configuration) applyLegacyRoles() {
+	legacy := map[string]string{
+		"data_entry_applicant_name":     ROLE_APPLICANT,
+		"data_entry_object_description": ROLE_OBJECT,
+		"data_entry_object_class":       ROLE_CLASS,
+		"data_entry_object_image":       ROLE_IMAGE,
+	}
+	legacyReviewer := regexp.MustCompile(`^reviewer_\d+$`)
+
+	for gi, g := range c.DataCollection {
+		for fi, fc := range g.Fields {
+			key := BuildFieldKey(g.Key, fc.Key)
+			if role, ok := legacy[key]; ok {
+				c.DataCollection[gi].Fields[fi].Role = role
+			} else if legacyReviewer.MatchString(key) {
+				c.DataCollection[gi].Fields[fi].Role = ROLE_REVIEWER
+			}
+		}
+	}
+	for gi, g := range c.Evaluation {
+		for fi, fc := range g.Fields {
+			key := BuildFieldKey(g.Key, fc.Key)
+			if fc.Type == "range" && strings.HasSuffix(key, "_rating") {
+				c.Evaluation[gi].Fields[fi].Role = ROLE_SCORE
+			} else if fc.Type == "text" && strings.HasSuffix(key, "_comment") {
+				c.Evaluation[gi].Fields[fi].Role = ROLE_COMMENT
+			}
+		}
+	}
+}
+
+// validateRoles checks that roles are known, used where they make sense
+// and that the roles the application depends on are present.
+func (c Configuration) validateRoles() error {
+	dataRoles := map[string]bool{ROLE_APPLICANT: true, ROLE_OBJECT: true, ROLE_CLASS: true, ROLE_TRACK: true, ROLE_IMAGE: true, ROLE_REVIEWER: true}
+	evaluationRoles := map[string]bool{ROLE_SCORE: true, ROLE_COMMENT: true}
+
+	seen := make(map[string]string)
+	for _, g := range c.DataCollection {
+		for _, fc := range g.Fields {
+			if fc.Role == "" {
+				continue
+			}
+			where := fmt.Sprintf("datacollection %s.%s", g.Key, fc.Key)
+			if evaluationRoles[fc.Role] {
+				return fc.pos.errorf("%s: role %q is only allowed in evaluation groups", where, fc.Role)
+			}
+			if !dataRoles[fc.Role] {
+				return fc.pos.errorf("%s: unknown role %q", where, fc.Role)
+			}
+			if fc.Role == ROLE_REVIEWER {
+				continue
+			}
+			if other, ok := seen[fc.Role]; ok {
+				return fc.pos.errorf("%s: role %q is already used by %s", where, fc.Role, other)
+			}
+			seen[fc.Role] = where
+		}
+	}
+
+	for _, role := range []string{ROLE_APPLICANT, ROLE_OBJECT} {
+		if _, ok := seen[role]; !ok {
+			return fmt.Errorf("datacollection: no field with role %q, add `role: %s` to the field holding it", role, role)
+		}
+	}
+
+	scores := 0
+	for _, g := range c.Evaluation {
+		inGroup := make(map[string]string)
+		for _, fc := range g.Fields {
+			if fc.Role == "" {
+				continue
+			}
+			where := fmt.Sprintf("evaluation %s.%s", g.Key, fc.Key)
+			if dataRoles[fc.Role] {
+				return fc.pos.errorf("%s: role %q is only allowed in the data collection", where, fc.Role)
+			}
+			if !evaluationRoles[fc.Role] {
+				return fc.pos.errorf("%s: unknown role %q", where, fc.Role)
+			}
+			if other, ok := inGroup[fc.Role]; ok {
+				return fc.pos.errorf("%s: role %q is already used by %s", where, fc.Role, other)
+			}
+			inGroup[fc.Role] = where
+			if fc.Role == ROLE_SCORE {
+				if fc.Type != "range" {
+					return fc.pos.errorf("%s: role %q requires a field of type range", where, fc.Role)
+				}
+				scores++
+			}
+		}
+	}
+	if scores == 0 {
+		return fmt.Errorf("evaluation: no field with role %q, at least one group needs a rating", ROLE_SCORE)
+	}
+
+	return nil
+}
+
+// validateFields checks the keys and the type specific settings of all
+// fields. Group keys are unique across the data collection and the
+// evaluation, field keys within their group.
+func (c Configuration) validateFields() error {
+	seen := make(map[string]string)
+	for _, form := range []struct {
+		name   string
+		groups []GroupConfig
+	}{{"datacollection", c.DataCollection}, {"evaluation", c.Evaluation}} {
+		for _, g := range form.groups {
+			where := fmt.Sprintf("%s %s", form.name, g.Key)
+			if strings.TrimSpace(g.Key) == "" {
+				return g.pos.errorf("%s: group without key", form.name)
+			}
+			if other, ok := seen[g.Key]; ok {
+				return g.pos.errorf("%s: key %q is already used by %s", where, g.Key, other)
+			}
+			seen[g.Key] = where
+		}
+	}
+
+	if err := validateFormFields(c.DataCollection, nil); err != nil {
+		return err
+	}
+	return validateFormFields(c.Evaluation, fieldKeys(c.DataCollection))
+}
+
+// fieldKeys returns the keys of all fields of the groups in order.
+func fieldKeys(groups []GroupConfig) []string {
+	var keys []string
+	for _, g := range groups {
+		for _, fc := range g.Fields {
+			keys = append(keys, BuildFieldKey(g.Key, fc.Key))
+		}
+	}
+	return keys
+}
+
+// validateFormFields checks the fields of the groups of a single form,
+// earlier holds the keys of the fields of the forms filled in before. The
+// conditions of rules may refer to any of these fields or those of the
+// form, show_if conditions only to the fields asked before.
+func validateFormFields(groups []GroupConfig, earlier []string) error {
+	keys := append(append([]string{}, earlier...), fieldKeys(groups)...)
+	before := append([]string{}, earlier...)
+
+	for _, g := range groups {
+		if err := g.ShowIf.validate(g.Key, before); err != nil {
+			return g.pos.wrap(fmt.Errorf("%s: show_if: %w", g.Key, err))
+		}
+		seen := make(map[string]bool)
+		for _, fc := range g.Fields {
+			where := fmt.Sprintf("%s.%s", g.Key, fc.Key)
+			if strings.TrimSpace(fc.Key) == "" {
+				return fc.pos.errorf("%s: field without key", g.Key)
+			}
+			if seen[fc.Key] {
+				return fc.pos.errorf("%s: key %q is used by more than one field of the group", where, fc.Key)
+			}
+			seen[fc.Key] = true
+			if err := fc.validate(g, keys, before); err != nil {
+				return fc.pos.wrap(fmt.Errorf("%s: %w", where, err))
+			}
+			before = append(before, BuildFieldKey(g.Key, fc.Key))
+		}
+	}
+	return nil
+}
+
+// validate checks the settings of a field of group g, keys and before are
+// the field keys conditions may refer to as described on
+// validateFormFields.
+func (fc FieldConfig) validate(g GroupConfig, keys, before []string) error {
+	if !fieldTypes[fc.Type] {
+		return fmt.Errorf("unknown field type %q", fc.Type)
+	}
+	if err := fc.validateRules(g.Key, keys); err != nil {
+		return err
+	}
+	if err := fc.ShowIf.validate(g.Key, before); err != nil {
+		return fmt.Errorf("show_if: %w", err)
+	}
+	// scored groups can only be hidden as a whole
+	hidden := len(fc.ShowIf) > 0 || (len(g.ShowIf) > 0 && fc.Role != ROLE_SCORE)
+	if hidden && (fc.Role == ROLE_APPLICANT || fc.Role == ROLE_OBJECT || fc.Role == ROLE_SCORE) {
+		return fmt.Errorf("show_if: a field with role %s cannot be hidden", fc.Role)
+	}
+	if fc.Weight < 0 {
+		return fmt.Errorf("weight must not be negative")
+	}
+	switch fc.Type {
+	case "select", "multiselect":
+		if len(fc.Options) == 0 {
+			return fmt.Errorf("a %s needs options", fc.Type)
+		}
+	case "ranking":
+		if len(fc.Options) < 2 {
+			return fmt.Errorf("a ranking needs at least 2 options")
+		}
+	case "likert":
+		if len(fc.Statements) == 0 {
+			return fmt.Errorf("a likert field needs statements")
+		}
+	case "number":
+		if fc.Min != nil && fc.Max != nil && *fc.Min > *fc.Max {
+			return fmt.Errorf("min %s exceeds max %s", formatRating(*fc.Min), formatRating(*fc.Max))
+		}
+	}
+	if fc.Scale == nil {
+		return nil
+	}
+	if fc.Type != "range" && fc.Type != "likert" {
+		return fmt.Errorf("scale is only supported by range and likert fields")
+	}
+	return fc.Scale.validate()
+}
+
+// validate checks the configuration for settings which cannot work.
+func (c Configuration) validate() error {
+	if err := c.validateRoles(); err != nil {
+		return err
+	}
+	if err := validateAggregation(c.Aggregation); err != nil {
+		return fmt.Errorf("aggregation: %w", err)
+	}
+	if c.Formula != "" {
+		if c.Aggregation != "" {
+			return c.formulaPos.errorf("formula: aggregation and formula cannot be combined")
+		}
+		if c.total == nil {
+			return c.formulaPos.errorf("formula: not parsed")
+		}
+		if err := checkFormulaGroups(c.total, c.Evaluation); err != nil {
+			return c.formulaPos.errorf("formula: %w", err)
+		}
+	}
+	for _, g := range c.Evaluation {
+		if err := validateAggregation(g.Aggregation); err != nil {
+			return g.pos.wrap(fmt.Errorf("evaluation %s: %w", g.Key, err))
+		}
+	}
+	if c.Scale != nil {
+		if err := c.Scale.validate(); err != nil {
+			return err
+		}
+	}
+	if err := c.validateFields(); err != nil {
+		return err
+	}
+	if err := c.validateLevels(); err != nil {
+		return err
+	}
+	if err := c.validateTracks(); err != nil {
+		return err
+	}
+	if c.ReviewPanel.Min < 0 || c.ReviewPanel.Max < 0 {
+		return fmt.Errorf("review_panel: min and max must not be negative")
+	}
+	if c.ReviewPanel.Max > 0 && c.ReviewPanel.MinSize() > c.ReviewPanel.Max {
+		return fmt.Errorf("review_panel: min %d exceeds max %d", c.ReviewPanel.MinSize(), c.ReviewPanel.Max)
+	}
+	return c.validateClasses()
+}
+
 func saveConfiguration(path string, config Configuration) error {
 
 	logger.Println("Saving configuration to: ", path)
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"gopkg.in/yaml.v3"
+)
+
+// position is the line and column of an entry of the configuration file,
+// zero for entries which were not read from a file.
+type position struct {
+	Line   int
+	Column int
+}
+
+// configError is an error at a position of the configuration file.
+type configError struct {
+	position
+	err error
+}
+
+func (e *configError) Error() string {
+	return e.err.Error()
+}
+
+func (e *configError) Unwrap() error {
+	return e.err
+}
+
+// errorf returns an error at the position.
+func (p position) errorf(format string, args ...any) error {
+	return p.wrap(fmt.Errorf(format, args...))
+}
+
+// wrap attaches the position to err, unless p is unknown or err already
+// has a more precise one.
+func (p position) wrap(err error) error {
+	var ce *configError
+	if err == nil || p.Line == 0 || errors.As(err, &ce) {
+		return err
+	}
+	return &configError{position: p, err: err}
+}
+
+func nodePosition(n *yaml.Node) position {
+	return position{Line: n.Line, Column: n.Column}
+}
+
+// The entries validated one by one remember their position, so errors
+// found by validate point into the file.
+
+func (g *GroupConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain GroupConfig
+	if err := node.Decode((*plain)(g)); err != nil {
+		return err
+	}
+	g.pos = nodePosition(node)
+	return nil
+}
+
+func (fc *FieldConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain FieldConfig
+	if err := node.Decode((*plain)(fc)); err != nil {
+		return err
+	}
+	fc.pos = nodePosition(node)
+	return nil
+}
+
+func (l *SkillLevelConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain SkillLevelConfig
+	if err := node.Decode((*plain)(l)); err != nil {
+		return err
+	}
+	l.pos = nodePosition(node)
+	return nil
+}
+
+func (t *TrackConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain TrackConfig
+	if err := node.Decode((*plain)(t)); err != nil {
+		return err
+	}
+	t.pos = nodePosition(node)
+	return nil
+}
+
+func (cc *ClassConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain ClassConfig
+	if err := node.Decode((*plain)(cc)); err != nil {
+		return err
+	}
+	cc.pos = nodePosition(node)
+	return nil
+}
+
+// yamlSyntaxError matches the line of syntax errors reported by yaml.v3.
+var yamlSyntaxError = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// parseConfiguration decodes and validates a configuration. Keys which are
+// no settings and values of the wrong type are reported all at once,
+// settings which cannot work one at a time.
+func parseConfiguration(data []byte) (Configuration, error) {
+	var configuration Configuration
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		if m := yamlSyntaxError.FindStringSubmatch(err.Error()); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			return configuration, &configError{position: position{Line: line}, err: errors.New(m[2])}
+		}
+		return configuration, err
+	}
+	if err := checkNode(&root, reflect.TypeOf(configuration)); err != nil {
+		return configuration, err
+	}
+	if err := root.Decode(&configuration); err != nil {
+		return configuration, err
+	}
+
+	if !configuration.hasRoles() {
+		logger.Println("Configuration declares no field roles, deriving them from the field keys.")
+		configuration.applyLegacyRoles()
+	}
+	configuration.applyLegacyLevels()
+	configuration.applyTrackOptions()
+	if err := configuration.parseFormula(&root); err != nil {
+		return configuration, err
+	}
+
+	return configuration, configuration.validate()
+}
+
+// parseFormula parses Formula once, scoring only evaluates the result.
+func (c *Configuration) parseFormula(root *yaml.Node) error {
+	if c.Formula == "" {
+		return nil
+	}
+	if n := mappingValue(root, "formula"); n != nil {
+		c.formulaPos = nodePosition(n)
+	}
+	f, err := parseFormula(c.Formula)
+	if err != nil {
+		return c.formulaPos.errorf("formula: %w", err)
+	}
+	c.total = f
+	return nil
+}
+
+// mappingValue returns the value of key in the mapping of the document n,
+// nil if there is none.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// checkNode checks that the mappings below n only use the keys of the
+// structs they are decoded into and that scalars fit their type.
+func checkNode(n *yaml.Node, t reflect.Type) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil
+		}
+		return checkNode(n.Content[0], t)
+	case yaml.AliasNode:
+		return checkNode(n.Alias, t)
+	}
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!null" {
+		return nil
+	}
+
+	var errs []error
+	switch t.Kind() {
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			return nodePosition(n).errorf("expected a mapping")
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			ft, ok := fields[k.Value]
+			if !ok {
+				errs = append(errs, nodePosition(k).errorf("unknown key %q%s", k.Value, suggestKey(k.Value, fields)))
+				continue
+			}
+			errs = append(errs, checkNode(v, ft))
+		}
+	case reflect.Slice:
+		if n.Kind != yaml.SequenceNode {
+			// lists like show_if may be given as a single entry
+			return checkNode(n, t.Elem())
+		}
+		for _, c := range n.Content {
+			errs = append(errs, checkNode(c, t.Elem()))
+		}
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			return nodePosition(n).errorf("expected a mapping")
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			errs = append(errs, checkNode(n.Content[i+1], t.Elem()))
+		}
+	case reflect.Interface:
+	default:
+		if n.Kind != yaml.ScalarNode {
+			return nodePosition(n).errorf("expected a single value")
+		}
+		if err := n.Decode(reflect.New(t).Interface()); err != nil {
+			return nodePosition(n).errorf("%q is no valid %s", n.Value, typeName(t))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// yamlFields returns the types of the fields of a struct by their yaml key.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			for k, ft := range yamlFields(f.Type) {
+				fields[k] = ft
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// suggestKey proposes the known key closest to a misspelt one.
+func suggestKey(key string, fields map[string]reflect.Type) string {
+	best, dist := "", 3
+	for k := range fields {
+		if d := editDistance(key, k); d < dist {
+			best, dist = k, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+// editDistance is the Levenshtein distance of a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := range ra {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i + 1
+		for j := range rb {
+			cost := 1
+			if ra[i] == rb[j] {
+				cost = 0
+			}
+			cur[j+1] = min(min(prev[j+1]+1, cur[j]+1), prev[j]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "yes/no value"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "whole number"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	}
+	return "value"
+}
+
+// formatConfigErrors renders the errors of the configuration at path one
+// per line, prefixed with their position if known, e.g.
+// `config.yaml:12:5: unknown key "titel", did you mean "title"?`.
+func formatConfigErrors(path string, err error) error {
+	var lines []string
+	for _, err := range flattenErrors(err) {
+		var ce *configError
+		switch {
+		case !errors.As(err, &ce):
+			lines = append(lines, fmt.Sprintf("%s: %v", path, err))
+		case ce.Column > 0:
+			lines = append(lines, fmt.Sprintf("%s:%d:%d: %v", path, ce.Line, ce.Column, err))
+		default:
+			lines = append(lines, fmt.Sprintf("%s:%d: %v", path, ce.Line, err))
+		}
+	}
+	return errors.New(strings.Join(lines, "\n"))
+}
+
+// flattenErrors returns the errors joined in err.
+func flattenErrors(err error) []error {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var res []error
+	for _, e := range joined.Unwrap() {
+		res = append(res, flattenErrors(e)...)
+	}
+	return res
+}
+
+// runConfig implements the `config` command.
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "validate" {
+		return fmt.Errorf("config: expected validate")
+	}
+
+	flags := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	positional, err := parseArgs(flags, args[1:])
+	if err != nil {
+		return err
+	}
+	path := getConfigurationFilePath()
+	switch len(positional) {
+	case 0:
+	case 1:
+		path = positional[0]
+	default:
+		return fmt.Errorf("config validate: expected at most one file")
+	}
+
+	if _, err := loadConfiguration(path); err != nil {
+		return err
+	}
+	fmt.Printf("%s: ok\n", path)
+	return nil
+}
+
+// configErrorModel is the screen shown instead of the ceremony when the
+// configuration is broken. Any key quits.
+type configErrorModel struct {
+	Model
+	err error
+}
+
+func newConfigErrorModel(err error) configErrorModel {
+	m := Model{Lg: lipgloss.DefaultRenderer(), width: maxWidth}
+	m.Styles = NewStyles(m.Lg)
+	return configErrorModel{Model: m, err: err}
+}
+
+func (m configErrorModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m configErrorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = min(msg.Width, maxWidth) - m.Styles.Base.GetHorizontalFrameSize()
+	case tea.KeyMsg:
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m configErrorModel) View() string {
+	s := m.Styles
+
+	var b strings.Builder
+	b.WriteString("\nDie Konfiguration enthält Fehler, die Zeremonie kann nicht gestartet werden:\n\n")
+	for _, line := range strings.Split(m.err.Error(), "\n") {
+		b.WriteString(s.Highlight.Render(line) + "\n")
+	}
+	b.WriteString("\nBitte korrigieren Sie die Datei und prüfen Sie sie mit `ceremonymaster config validate`.")
+
+	return s.Base.Render(m.appErrorBoundaryView("Ceremony Master - Konfigurationsfehler") + "\n" + b.String() + "\n\n" +
+		m.appBoundaryView("Beliebige Taste zum Beenden"))
+}
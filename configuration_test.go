@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// The default configuration written by earlier versions numbers its two
+// highest levels 4, it must still load.
+func TestParseLegacyConfiguration(t *testing.T) {
+	data, err := os.ReadFile("testdata/legacy-config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := parseConfiguration(data)
+	if err != nil {
+		t.Fatalf("parseConfiguration: %v", err)
+	}
+
+	var numbers []int
+	for _, l := range cfg.SkillLevels {
+		numbers = append(numbers, l.Level)
+	}
+	want := []int{0, 1, 2, 3, 4, 5}
+	if len(numbers) != len(want) {
+		t.Fatalf("levels = %v, want %v", numbers, want)
+	}
+	for i := range want {
+		if numbers[i] != want[i] {
+			t.Fatalf("levels = %v, want %v", numbers, want)
+		}
+	}
+}
+
+func TestDefaultConfigurationIsValid(t *testing.T) {
+	if err := defaultConfiguration().validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseConfigurationFormula(t *testing.T) {
+	data, err := os.ReadFile("testdata/legacy-config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := parseConfiguration(append([]byte("formula: 0.5*taste + 0.5*smell\n"), data...))
+	if err != nil {
+		t.Fatalf("parseConfiguration: %v", err)
+	}
+	if cfg.total == nil {
+		t.Fatal("formula not parsed")
+	}
+
+	for formula, want := range map[string]string{
+		"0.5*taste + max(smell": "config.yaml:1:10: formula: unexpected end of formula at position 22",
+		"0.5*tast + smell":      `config.yaml:1:10: formula: unknown group "tast" at position 5`,
+	} {
+		_, err := parseConfiguration(append([]byte("formula: "+formula+"\n"), data...))
+		if err == nil {
+			t.Errorf("%s: no error", formula)
+			continue
+		}
+		if got := formatConfigErrors("config.yaml", err).Error(); got != want {
+			t.Errorf("%s: got %s, want %s", formula, got, want)
+		}
+	}
+}
+
+// A group hidden by show_if has no result the formula could use.
+func TestParseConfigurationFormulaHiddenGroup(t *testing.T) {
+	data, err := os.ReadFile("testdata/legacy-config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = bytes.Replace(data, []byte("    - key: taste\n"), []byte(`    - key: taste
+      show_if:
+        field: data_entry.object_class
+        equals: Torte
+`), 1)
+
+	if _, err := parseConfiguration(append([]byte("formula: 0.5*smell + 0.5*innovation\n"), data...)); err != nil {
+		t.Fatalf("formula without the hidden group: %v", err)
+	}
+
+	_, err = parseConfiguration(append([]byte("formula: 0.5*smell + 0.5*taste\n"), data...))
+	want := `config.yaml:1:10: formula: group "taste" at position 17 has show_if conditions, a formula can only use groups which are always asked`
+	if err == nil {
+		t.Fatal("formula with the hidden group: no error")
+	}
+	if got := formatConfigErrors("config.yaml", err).Error(); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
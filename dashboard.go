@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const STATE_DASHBOARD = "dashboard"
+
+// newReviewerInput creates the input for adding reviewers which completes
+// names of the roster with tab.
+func newReviewerInput(suggestions []string) textinput.Model {
+	input := textinput.New()
+	input.Prompt = "Neuer Zertifizierer: "
+	input.Placeholder = "Name oder Kürzel"
+	input.Width = 30
+	input.ShowSuggestions = true
+	input.SetSuggestions(suggestions)
+	return input
+}
+
+// showDashboard switches to the reviewer dashboard highlighting the next
+// reviewer without a review, or the summary entry once all are done. With
+// an empty panel it starts adding reviewers right away.
+func (m *Model) showDashboard() tea.Cmd {
+	m.State = STATE_DASHBOARD
+
+	if len(m.Evaluation.Reviewers) == 0 {
+		m.Evaluation.DashboardIndex = 0
+		m.Evaluation.Adding = true
+		return m.Evaluation.AddInput.Focus()
+	}
+
+	idxs := m.Evaluation.reviewerIdxs()
+	m.Evaluation.DashboardIndex = len(idxs)
+	next := m.Evaluation.getNextReviewerIdx()
+	for i, idx := range idxs {
+		if idx == next {
+			m.Evaluation.DashboardIndex = i
+			break
+		}
+	}
+	return nil
+}
+
+// updateReviewerInput handles the input line for adding reviewers. Each
+// name confirmed with Enter is added to the panel, an empty line or Esc
+// ends the input.
+func (m *Model) updateReviewerInput(msg tea.Msg) []tea.Cmd {
+	cmds := []tea.Cmd{}
+	e := &m.Evaluation
+
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "enter":
+			name := e.AddInput.Value()
+			if strings.TrimSpace(name) == "" {
+				e.Adding = false
+				e.AddInput.Blur()
+				return cmds
+			}
+			if _, err := m.addReviewer(name); err != nil {
+				e.Notice = err.Error()
+				return cmds
+			}
+			e.Notice = ""
+			e.AddInput.Reset()
+			m.autosave()
+			if m.Cfg.ReviewPanel.Full(len(e.Reviewers)) {
+				e.Adding = false
+				e.AddInput.Blur()
+			}
+			return cmds
+		case "esc":
+			e.Adding = false
+			e.AddInput.Blur()
+			e.AddInput.Reset()
+			return cmds
+		}
+	}
+
+	var cmd tea.Cmd
+	e.AddInput, cmd = e.AddInput.Update(msg)
+	return append(cmds, cmd)
+}
+
+// toggleAbsent marks a reviewer absent or, if already absent, present again.
+// A returning reviewer continues with the answers of a completed review, if
+// any.
+func (m *Model) toggleAbsent(reviewerIdx int) {
+	r, ok := m.Evaluation.Reviewers[reviewerIdx]
+	if !ok {
+		return
+	}
+
+	if r.status != REVIEW_ABSENT {
+		r.status = REVIEW_ABSENT
+	} else if _, ok := m.Evaluation.Answers[reviewerIdx]; ok {
+		r.status = REVIEW_DONE
+	} else {
+		r.status = REVIEW_PENDING
+		m.Evaluation.Forms[reviewerIdx] = m.newEvaluationForm(nil)
+	}
+	m.Evaluation.Reviewers[reviewerIdx] = r
+}
+
+func (m *Model) UpdateDashboard(msg tea.Msg) []tea.Cmd {
+	cmds := []tea.Cmd{}
+
+	if m.State != STATE_DASHBOARD {
+		return cmds
+	}
+
+	if m.Evaluation.Adding {
+		return m.updateReviewerInput(msg)
+	}
+
+	if m.interceptAbort(msg) {
+		return cmds
+	}
+
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return cmds
+	}
+
+	e := &m.Evaluation
+	idxs := e.reviewerIdxs()
+	e.Notice = ""
+
+	switch key.String() {
+	case "up", "k":
+		if e.DashboardIndex > 0 {
+			e.DashboardIndex--
+		}
+	case "down", "j":
+		if e.DashboardIndex < len(idxs) {
+			e.DashboardIndex++
+		}
+	case "a":
+		if e.DashboardIndex < len(idxs) {
+			m.toggleAbsent(idxs[e.DashboardIndex])
+			m.autosave()
+		}
+	case "n", "+":
+		if m.Cfg.ReviewPanel.Full(len(idxs)) {
+			e.Notice = fmt.Sprintf("Das Panel ist mit %d Zertifizierern voll.", m.Cfg.ReviewPanel.Max)
+			break
+		}
+		e.Adding = true
+		cmds = append(cmds, e.AddInput.Focus())
+	case "x", "delete":
+		if e.DashboardIndex < len(idxs) {
+			if err := m.removeReviewer(idxs[e.DashboardIndex]); err != nil {
+				e.Notice = err.Error()
+				break
+			}
+			e.DashboardIndex = min(e.DashboardIndex, len(idxs)-1)
+			m.autosave()
+		}
+	case "enter":
+		if e.DashboardIndex < len(idxs) {
+			if cmd := m.openReview(idxs[e.DashboardIndex]); cmd != nil {
+				cmds = append(cmds, cmd, tea.ClearScreen)
+			}
+			break
+		}
+
+		// the last row leads to the summary
+		switch {
+		case e.hasPendingReviews():
+			e.Notice = "Es fehlen noch Bewertungen, abwesende Zertifizierer bitte mit 'a' markieren."
+		case len(e.sheets()) < m.Cfg.ReviewPanel.MinSize():
+			e.Notice = fmt.Sprintf("Mindestens %d abgeschlossene Bewertungen sind erforderlich.", m.Cfg.ReviewPanel.MinSize())
+		default:
+			m.State = STATE_SUMMARY
+			m.refreshSummary()
+			m.autosave()
+			cmds = append(cmds, tea.ClearScreen)
+		}
+	}
+
+	return cmds
+}
+
+func (m *Model) ViewDashboard() (header string, body string, footer string) {
+	s := m.Styles
+	e := m.Evaluation
+	header = "Übersicht der Zertifizierer"
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s beantragt die Zertifizierung von %s.\n\n",
+		s.Highlight.Render(m.applicantName), s.Highlight.Render(m.objectName))
+
+	idxs := e.reviewerIdxs()
+	for i, idx := range idxs {
+		label := fmt.Sprintf("%-30s %s", truncate(m.getReviewerName(idx), 30), e.Reviewers[idx].status)
+		if i == e.DashboardIndex {
+			fmt.Fprintf(&b, "> %s\n", s.Highlight.Render(label))
+		} else {
+			fmt.Fprintf(&b, "  %s\n", label)
+		}
+	}
+
+	if len(idxs) == 0 {
+		b.WriteString("  Noch keine Zertifizierer im Panel.\n")
+	}
+	if e.Adding {
+		fmt.Fprintf(&b, "\n  %s\n", e.AddInput.View())
+	}
+
+	reviews := "Bewertungen"
+	if m.Cfg.ReviewPanel.MinSize() == 1 {
+		reviews = "Bewertung"
+	}
+	size := fmt.Sprintf("Panel: %d Zertifizierer, mindestens %d %s", len(idxs), m.Cfg.ReviewPanel.MinSize(), reviews)
+	if m.Cfg.ReviewPanel.Max > 0 {
+		size += fmt.Sprintf(", höchstens %d Zertifizierer", m.Cfg.ReviewPanel.Max)
+	}
+	fmt.Fprintf(&b, "\n%s\n", s.Help.Render(size))
+
+	label := "Weiter zur Zusammenfassung"
+	if e.DashboardIndex == len(idxs) {
+		fmt.Fprintf(&b, "\n> %s\n", s.Highlight.Render(label))
+	} else {
+		fmt.Fprintf(&b, "\n  %s\n", label)
+	}
+
+	if e.Notice != "" {
+		fmt.Fprintf(&b, "\n%s\n", s.StatusHeader.Render(e.Notice))
+	}
+
+	body = b.String()
+	help := "Enter bewerten/korrigieren · n hinzufügen · x entfernen · a abwesend · Esc abbrechen"
+	if e.Adding {
+		help = "Enter hinzufügen · Tab vervollständigen · leere Eingabe oder Esc beendet"
+	}
+	footer = m.appBoundaryView(help)
+	return header, body, footer
+}
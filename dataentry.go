@@ -13,20 +13,30 @@ import (
 type DataEntryModel struct {
 	Form      *huh.Form
 	Reviewers []string
+	// Answers holds the values of all fields completed so far.
+	Answers answerSheet
+	// Error is shown above the form when the completed answers were
+	// rejected.
+	Error string
 }
 
 func (m *Model) InitDataEntryModel() {
-
-	groups := m.buildGroups(m.Cfg.DataCollection)
-
 	m.DataEntry = DataEntryModel{
-		Form: huh.NewForm(groups...).
-			WithWidth(80).
-			WithShowHelp(false).
-			WithShowErrors(true),
+		Form:    m.newDataEntryForm(nil),
+		Answers: make(answerSheet),
 	}
 }
 
+// newDataEntryForm builds the data entry form prefilled with initial which
+// may be nil.
+func (m *Model) newDataEntryForm(initial answerSheet) *huh.Form {
+	groups, _ := m.buildGroups(m.Cfg.DataCollection, initial, nil)
+	return huh.NewForm(groups...).
+		WithWidth(80).
+		WithShowHelp(false).
+		WithShowErrors(true)
+}
+
 func (m *Model) UpdateDataEntryModel(msg tea.Msg) []tea.Cmd {
 
 	cmds := []tea.Cmd{}
@@ -35,23 +45,26 @@ func (m *Model) UpdateDataEntryModel(msg tea.Msg) []tea.Cmd {
 		return cmds
 	}
 
+	if m.interceptAbort(msg) {
+		return cmds
+	}
+
 	Form, cmd := m.DataEntry.Form.Update(msg)
 
 	if f, ok := Form.(*huh.Form); ok {
 		m.DataEntry.Form = f
 	}
 
+	// huh only stores the value of a field once it is left, so every
+	// change of the answers corresponds to a completed step
+	changed := m.DataEntry.Answers.merge(formAnswers(m.DataEntry.Form, m.Cfg.DataCollection))
+	dropHidden(m.Cfg.DataCollection, m.DataEntry.Answers, nil)
+
 	m.DataEntry.Reviewers = []string{}
-	for _, v := range m.Cfg.DataCollection {
-		groupKey := v.Key
-		for _, fc := range v.Fields {
-			fieldKey := BuildFieldKey(groupKey, fc.Key)
-			if strings.HasPrefix(fieldKey, "reviewer_") {
-				var reviewer = m.DataEntry.Form.GetString(fieldKey)
-				if reviewer != "" {
-					m.DataEntry.Reviewers = append(m.DataEntry.Reviewers, reviewer)
-				}
-			}
+	for _, fieldKey := range reviewerFields(m.Cfg.DataCollection) {
+		var reviewer = m.DataEntry.Answers.GetString(fieldKey)
+		if reviewer != "" {
+			m.DataEntry.Reviewers = append(m.DataEntry.Reviewers, reviewer)
 		}
 	}
 
@@ -60,15 +73,24 @@ func (m *Model) UpdateDataEntryModel(msg tea.Msg) []tea.Cmd {
 	// If the Form just completed, collect results and transition to
 	// the review State while initializing the evaluation Form.
 	if m.DataEntry.Form.State == huh.StateCompleted {
+		// a recertification must stay with the applicant and the track of
+		// the renewed certificate, the host corrects the answers
+		if err := m.recertificationError(); err != nil {
+			m.DataEntry.Error = err.Error()
+			m.DataEntry.Form = m.newDataEntryForm(m.DataEntry.Answers)
+			return append(cmds, m.DataEntry.Form.Init(), tea.ClearScreen)
+		}
+		m.DataEntry.Error = ""
+		m.completeDataEntry()
 
-		m.applicantName = m.DataEntry.Form.GetString("data_entry_applicant_name")
-		m.objectName = m.DataEntry.Form.GetString("data_entry_object_description")
-		m.objectImage = m.DataEntry.Form.GetString("data_entry_object_image")
-
-		// Transition to evaluation and initialize the evaluation Form.
-		m.State = "evaluation"
+		// Transition to the reviewer dashboard, the host picks who starts.
+		cmds = append(cmds, m.showDashboard(), tea.ClearScreen)
+		m.autosave()
 		// Do not append the Form's quit command to avoid exiting the app.
 	} else {
+		if changed {
+			m.autosave()
+		}
 		// Only append the Form cmd while it is still active.
 		cmds = append(cmds, cmd)
 	}
@@ -76,6 +98,45 @@ func (m *Model) UpdateDataEntryModel(msg tea.Msg) []tea.Cmd {
 	return cmds
 }
 
+// objectClass returns the class of the object entered in the data
+// collection, empty if the configuration doesn't ask for it.
+func (m *Model) objectClass() string {
+	return strings.TrimSpace(m.DataEntry.Answers.GetString(m.Cfg.DataKey(ROLE_CLASS)))
+}
+
+// track returns the track chosen in the data collection, empty if the
+// configuration doesn't ask for it.
+func (m *Model) track() string {
+	return strings.TrimSpace(m.DataEntry.Answers.GetString(m.Cfg.DataKey(ROLE_TRACK)))
+}
+
+// ceremonyCfg returns the configuration of the running ceremony, adapted to
+// its track and the class of the object, see Configuration.ForCeremony.
+func (m *Model) ceremonyCfg() Configuration {
+	return m.Cfg.ForCeremony(m.track(), m.objectClass())
+}
+
+// completeDataEntry takes over the ceremony details from the data entry
+// answers. Reviewer names entered in the data collection of older
+// configurations seed an empty review panel.
+func (m *Model) completeDataEntry() {
+	m.applicantName = m.DataEntry.Answers.GetString(m.Cfg.DataKey(ROLE_APPLICANT))
+	m.applicantStanding = loadStanding(m.ceremonyCfg(), m.applicantName)
+	m.objectName = m.DataEntry.Answers.GetString(m.Cfg.DataKey(ROLE_OBJECT))
+	m.objectImage = m.DataEntry.Answers.GetString(m.Cfg.DataKey(ROLE_IMAGE))
+
+	if len(m.Evaluation.Reviewers) > 0 {
+		return
+	}
+	for _, key := range reviewerFields(m.Cfg.DataCollection) {
+		if name := m.DataEntry.Answers.GetString(key); strings.TrimSpace(name) != "" {
+			if _, err := m.addReviewer(name); err != nil {
+				logger.Printf("Skipping reviewer %q: %v", name, err)
+			}
+		}
+	}
+}
+
 func (m *Model) ViewDataEntry() (header string, body string, footer string) {
 	s := m.Styles
 
@@ -93,6 +154,8 @@ func (m *Model) ViewDataEntry() (header string, body string, footer string) {
 		errors := m.DataEntry.Form.Errors()
 		if len(errors) > 0 {
 			header = m.appErrorBoundaryView(m.errorView(m.DataEntry.Form))
+		} else if m.DataEntry.Error != "" {
+			header = m.appErrorBoundaryView(m.DataEntry.Error)
 		}
 
 		// Status (right side)
@@ -105,18 +168,18 @@ func (m *Model) ViewDataEntry() (header string, body string, footer string) {
 				jobDescription    string
 			)
 
-			if m.DataEntry.Form.GetString("data_entry_applicant_name") != "" {
-				applicantName := m.DataEntry.Form.GetString("data_entry_applicant_name")
+			if m.DataEntry.Answers.GetString(m.Cfg.DataKey(ROLE_APPLICANT)) != "" {
+				applicantName := m.DataEntry.Answers.GetString(m.Cfg.DataKey(ROLE_APPLICANT))
 				buildInfo = m.Styles.Highlight.Render(applicantName)
 			}
 
-			if m.DataEntry.Form.GetString("data_entry_object_description") != "" {
-				objectDescription := m.DataEntry.Form.GetString("data_entry_object_description")
+			if m.DataEntry.Answers.GetString(m.Cfg.DataKey(ROLE_OBJECT)) != "" {
+				objectDescription := m.DataEntry.Answers.GetString(m.Cfg.DataKey(ROLE_OBJECT))
 				buildInfo += fmt.Sprintf(" beantragt die Zertifizierung von %s", m.Styles.Highlight.Render(objectDescription))
 			}
 
-			if m.DataEntry.Form.GetString("data_entry_object_class") != "" {
-				objectClass := m.DataEntry.Form.GetString("data_entry_object_class")
+			if m.DataEntry.Answers.GetString(m.Cfg.DataKey(ROLE_CLASS)) != "" {
+				objectClass := m.DataEntry.Answers.GetString(m.Cfg.DataKey(ROLE_CLASS))
 				buildInfo += fmt.Sprintf(" (Klasse: %s)", m.Styles.Highlight.Render(objectClass))
 			}
 
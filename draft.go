@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"gopkg.in/yaml.v3"
+)
+
+const DRAFT_FILE = "draft.yaml"
+
+// Draft is the autosaved state of an unfinished ceremony. It is written to
+// the data folder after every completed form step so the ceremony can be
+// resumed after a crash or an accidental quit, and removed once the
+// ceremony ends.
+type Draft struct {
+	Started time.Time `yaml:"started"`
+	Updated time.Time `yaml:"updated"`
+	// Stage is the state the ceremony was in when the draft was saved.
+	Stage     string                 `yaml:"stage"`
+	DataEntry map[string]any         `yaml:"data_entry"`
+	Panel     map[int]string         `yaml:"panel,omitempty"`
+	Reviews   map[int]map[string]any `yaml:"reviews,omitempty"`
+	// Absent lists the reviewers marked absent by their idx.
+	Absent []int `yaml:"absent,omitempty"`
+	// Recertifies is the ID of the certificate renewed by the ceremony.
+	Recertifies string `yaml:"recertifies,omitempty"`
+}
+
+func getDraftPath() string {
+	return path.Join(getDataPath(), DRAFT_FILE)
+}
+
+// loadDraft reads the draft of an unfinished ceremony. It returns nil
+// without an error if there is none.
+func loadDraft() (*Draft, error) {
+	data, err := os.ReadFile(getDraftPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var d Draft
+	if err := yaml.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("failed to parse draft %s: %w", getDraftPath(), err)
+	}
+	return &d, nil
+}
+
+func saveDraft(d Draft) error {
+	data, err := yaml.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file first so a crash while saving never leaves
+	// a truncated draft behind
+	tmp := getDraftPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, getDraftPath())
+}
+
+func removeDraft() {
+	if err := os.Remove(getDraftPath()); err != nil && !os.IsNotExist(err) {
+		logger.Printf("Failed to remove draft: %v", err)
+	}
+}
+
+// autosave stores the answers of the running ceremony as draft.
+func (m *Model) autosave() {
+	d := Draft{
+		Started:   m.startedAt,
+		Updated:   time.Now(),
+		Stage:     m.State,
+		DataEntry: m.DataEntry.Answers,
+		Panel:     make(map[int]string),
+		Reviews:   make(map[int]map[string]any),
+
+		Recertifies: m.recertifies,
+	}
+	for idx, r := range m.Evaluation.Reviewers {
+		d.Panel[idx] = r.name
+	}
+	for idx, sheet := range m.Evaluation.Answers {
+		d.Reviews[idx] = sheet
+	}
+	for _, idx := range m.Evaluation.reviewerIdxs() {
+		if m.Evaluation.Reviewers[idx].status == REVIEW_ABSENT {
+			d.Absent = append(d.Absent, idx)
+		}
+	}
+
+	if err := saveDraft(d); err != nil {
+		logger.Printf("Failed to save draft: %v", err)
+	}
+}
+
+// restoreAnswers converts the draft values of the given groups back into an
+// answerSheet, dropping values of fields which are no longer configured or
+// whose type changed in the meantime.
+func restoreAnswers(groups []GroupConfig, values map[string]any) answerSheet {
+	sheet := make(answerSheet)
+	for _, g := range groups {
+		for _, fc := range g.Fields {
+			key := BuildFieldKey(g.Key, fc.Key)
+			raw, ok := values[key]
+			if !ok {
+				continue
+			}
+			v, err := normalizeAnswer(fc, raw)
+			if err != nil {
+				logger.Printf("Dropping draft value of %s: %v", key, err)
+				continue
+			}
+			sheet[key] = v
+		}
+	}
+	return sheet
+}
+
+// resumeDraft rebuilds the forms of an unfinished ceremony prefilled with
+// the values of the draft and continues where it was interrupted: in the
+// data entry, the reviewer dashboard or the summary. Reviews which were in
+// progress start over.
+func (m *Model) resumeDraft(d *Draft) tea.Cmd {
+	m.resetCeremony()
+	m.startedAt = d.Started
+	m.recertifies = d.Recertifies
+
+	m.DataEntry.Answers = restoreAnswers(m.Cfg.DataCollection, d.DataEntry)
+	m.DataEntry.Form = m.newDataEntryForm(m.DataEntry.Answers)
+
+	if d.Stage == STATE_DATA_ENTRY {
+		m.State = STATE_DATA_ENTRY
+		return m.DataEntry.Form.Init()
+	}
+
+	e := &m.Evaluation
+	for idx, name := range d.Panel {
+		e.Reviewers[idx] = reviewer{name: name, idx: idx}
+		e.Forms[idx] = m.newEvaluationForm(nil)
+		e.NextReviewerIdx = max(e.NextReviewerIdx, idx+1)
+	}
+
+	m.completeDataEntry()
+
+	for idx, values := range d.Reviews {
+		r, ok := e.Reviewers[idx]
+		if !ok {
+			continue
+		}
+		e.Answers[idx] = restoreAnswers(m.ceremonyCfg().Evaluation, values)
+		r.status = REVIEW_DONE
+		e.Reviewers[idx] = r
+	}
+	for _, idx := range d.Absent {
+		if r, ok := e.Reviewers[idx]; ok {
+			r.status = REVIEW_ABSENT
+			e.Reviewers[idx] = r
+		}
+	}
+
+	if d.Stage == STATE_SUMMARY && !e.hasPendingReviews() && len(e.sheets()) >= m.Cfg.ReviewPanel.MinSize() {
+		m.State = STATE_SUMMARY
+		m.refreshSummary()
+		return nil
+	}
+
+	return m.showDashboard()
+}
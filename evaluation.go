@@ -2,99 +2,267 @@ package main
 
 import (
 	"fmt"
-	"math"
-	"regexp"
+	"reflect"
+	"slices"
 	"sort"
-	"strconv"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
 )
 
-// reviewer represents a reviewer key and its numeric index (e.g. reviewer_1 -> 1).
+// reviewStatus is the state of a reviewer's evaluation shown in the
+// reviewer dashboard.
+type reviewStatus int
+
+const (
+	REVIEW_PENDING reviewStatus = iota
+	REVIEW_IN_PROGRESS
+	REVIEW_DONE
+	REVIEW_ABSENT
+)
+
+func (s reviewStatus) String() string {
+	switch s {
+	case REVIEW_IN_PROGRESS:
+		return "in Bearbeitung"
+	case REVIEW_DONE:
+		return "abgeschlossen"
+	case REVIEW_ABSENT:
+		return "abwesend"
+	default:
+		return "offen"
+	}
+}
+
+// reviewer is a member of the review panel. idx identifies the reviewer
+// within the ceremony and gives the panel order.
 type reviewer struct {
-	key             string
-	idx             int
-	reviewCompleted bool
+	name   string
+	idx    int
+	status reviewStatus
+}
+
+// reviewForm is the evaluation form of a single reviewer together with the
+// variables its fields are bound to.
+type reviewForm struct {
+	*huh.Form
+	values fieldBindings
 }
 
 type EvaluationModel struct {
 	FormInitialized   bool
 	Form              *huh.Form
-	Forms             map[int]*huh.Form // per reviewer idx
+	Forms             map[int]*reviewForm // per reviewer idx
 	ActiveReviewerIdx int
 
 	Reviewers map[int]reviewer
-	//ReviewerLookup        map[string]reviewer
-	ReviewerReverseLookup map[int]string
 
-	// Results holds the finalized values after Form completion.
-	Results map[string]any
+	// Answers holds the answer sheet of each completed review keyed by
+	// reviewer idx. Results, summaries and certificates are derived from
+	// these sheets only.
+	Answers map[int]answerSheet
+
+	// NextReviewerIdx is the idx given to the next reviewer added to the panel.
+	NextReviewerIdx int
+
+	// DashboardIndex is the highlighted row of the reviewer dashboard.
+	DashboardIndex int
+	// AddInput takes the name of a reviewer joining the panel while Adding.
+	AddInput textinput.Model
+	Adding   bool
+	// Notice is shown in the dashboard until the next key press.
+	Notice string
 }
 
 func (m *Model) InitEvaluationModel() {
 
 	m.Evaluation = EvaluationModel{
-		ActiveReviewerIdx: math.MaxInt32,
+		ActiveReviewerIdx: 0,
 		Form:              nil,
-		Forms:             make(map[int]*huh.Form),
+		Forms:             make(map[int]*reviewForm),
 		Reviewers:         make(map[int]reviewer),
-		//ReviewerLookup:        make(map[string]reviewer),
-		ReviewerReverseLookup: make(map[int]string),
-		Results:               make(map[string]any),
+		Answers:           make(map[int]answerSheet),
+		NextReviewerIdx:   1,
+		AddInput:          newReviewerInput(m.Roster.Suggestions()),
 	}
+}
 
-	reviewerRe := regexp.MustCompile(`reviewer_(\d+)`)
-	for _, g := range m.Cfg.DataCollection {
-		groupKey := g.Key
+// addReviewer adds a reviewer to the panel and prepares an empty evaluation
+// form for them.
+func (m *Model) addReviewer(name string) (int, error) {
+	e := &m.Evaluation
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("Bitte einen Namen eingeben.")
+	}
+	// known reviewers keep the spelling of the roster and may be entered
+	// by their initials
+	if known := m.Roster.find(name); known != nil {
+		name = known.Name
+	}
+	for _, r := range e.Reviewers {
+		if strings.EqualFold(r.name, name) {
+			return 0, fmt.Errorf("%s ist bereits im Panel.", r.name)
+		}
+	}
+	if m.Cfg.ReviewPanel.Full(len(e.Reviewers)) {
+		return 0, fmt.Errorf("Das Panel ist mit %d Zertifizierern voll.", m.Cfg.ReviewPanel.Max)
+	}
+
+	idx := e.NextReviewerIdx
+	e.NextReviewerIdx++
+	e.Reviewers[idx] = reviewer{name: name, idx: idx}
+	e.Forms[idx] = m.newEvaluationForm(nil)
+
+	return idx, nil
+}
+
+// removeReviewer takes a reviewer off the panel. Completed reviews are kept,
+// such reviewers can only be marked absent.
+func (m *Model) removeReviewer(reviewerIdx int) error {
+	e := &m.Evaluation
+
+	r, ok := e.Reviewers[reviewerIdx]
+	if !ok {
+		return nil
+	}
+	if _, done := e.Answers[reviewerIdx]; done {
+		return fmt.Errorf("%s hat bereits bewertet, bitte stattdessen als abwesend markieren.", r.name)
+	}
+
+	delete(e.Reviewers, reviewerIdx)
+	delete(e.Forms, reviewerIdx)
+	return nil
+}
+
+// reviewerFields returns the keys of the data collection fields with the
+// reviewer role. Such fields are used by configurations with a fixed panel,
+// names entered there are taken over into the review panel.
+func reviewerFields(groups []GroupConfig) []string {
+	var res []string
+	for _, g := range groups {
 		for _, fc := range g.Fields {
-			fieldKey := BuildFieldKey(groupKey, fc.Key)
-			if sm := reviewerRe.FindStringSubmatch(fieldKey); sm != nil {
-				if n, err := strconv.Atoi(sm[1]); err == nil {
-					r := reviewer{key: fieldKey, idx: n} // reviewerName
-					m.Evaluation.Reviewers[n] = r
-				}
+			if fc.Role == ROLE_REVIEWER {
+				res = append(res, BuildFieldKey(g.Key, fc.Key))
 			}
 		}
 	}
+	return res
+}
 
-	minId := 9999999
-	for _, r := range m.Evaluation.Reviewers {
-		minId = min(minId, r.idx)
-	}
+// fieldReader gives read access to the values of a completed form keyed by
+// the field key built with BuildFieldKey. *huh.Form satisfies it, as does
+// answerSheet for values which were not entered through the TUI.
+type fieldReader interface {
+	Get(key string) any
+	GetString(key string) string
+}
 
-	m.Evaluation.ActiveReviewerIdx = minId
+// answerSheet holds form values keyed by field key.
+type answerSheet map[string]any
 
-	for _, reviewer := range m.Evaluation.Reviewers {
-		reviewerEvaluationGroups := m.buildGroups(m.Cfg.Evaluation)
-		reviewerForm := huh.NewForm(reviewerEvaluationGroups...).
-			WithWidth(80).
-			WithShowHelp(false).
-			WithShowErrors(true)
+func (a answerSheet) Get(key string) any {
+	return a[key]
+}
 
-		m.Evaluation.Forms[reviewer.idx] = reviewerForm
+func (a answerSheet) GetString(key string) string {
+	v, ok := a[key].(string)
+	if !ok {
+		return ""
+	}
+	return v
+}
 
+// merge copies the values of other into a and reports whether any value
+// changed.
+func (a answerSheet) merge(other answerSheet) bool {
+	changed := false
+	for k, v := range other {
+		if old, ok := a[k]; !ok || !reflect.DeepEqual(old, v) {
+			a[k] = v
+			changed = true
+		}
 	}
+	return changed
+}
 
-	m.Evaluation.FormInitialized = false
-	m.Evaluation.Form = m.Evaluation.Forms[m.Evaluation.ActiveReviewerIdx]
+// sheets returns the answers of all completed reviews keyed by reviewer idx.
+// Reviews of reviewers marked absent are left out.
+func (m *EvaluationModel) sheets() map[int]fieldReader {
+	res := make(map[int]fieldReader, len(m.Answers))
+	for idx, sheet := range m.Answers {
+		if m.Reviewers[idx].status == REVIEW_DONE {
+			res[idx] = sheet
+		}
+	}
+	return res
 }
 
-func buildReviewerKey(prefix string, revreviewer reviewer) string {
-	return fmt.Sprintf("%s_reviewer_%d", prefix, revreviewer.idx)
+// newEvaluationForm builds the evaluation form of a single reviewer,
+// prefilled with initial which may be nil.
+func (m *Model) newEvaluationForm(initial answerSheet) *reviewForm {
+	reviewerEvaluationGroups, values := m.buildGroups(m.ceremonyCfg().Evaluation, initial, m.DataEntry.Answers)
+	form := huh.NewForm(reviewerEvaluationGroups...).
+		WithWidth(80).
+		WithShowHelp(false).
+		WithShowErrors(true)
+	return &reviewForm{Form: form, values: values}
 }
 
-func (m *Model) getReviewerName(reviewerIdx int) string {
+// openReview makes the form of the reviewer the active one. A pending review
+// starts with the empty form, one in progress continues where it was left
+// and a completed review is reopened with a new form prefilled with its
+// answers.
+func (m *Model) openReview(reviewerIdx int) tea.Cmd {
+	r, ok := m.Evaluation.Reviewers[reviewerIdx]
+	if !ok || r.status == REVIEW_ABSENT {
+		return nil
+	}
+
+	form := m.Evaluation.Forms[reviewerIdx]
+	if r.status == REVIEW_DONE || form == nil {
+		form = m.newEvaluationForm(m.Evaluation.Answers[reviewerIdx])
+		m.Evaluation.Forms[reviewerIdx] = form
+	}
+	m.Evaluation.Form = form.Form
+	m.Evaluation.ActiveReviewerIdx = reviewerIdx
+
+	r.status = REVIEW_IN_PROGRESS
+	m.Evaluation.Reviewers[reviewerIdx] = r
 
-	reviewerKey := m.Evaluation.Reviewers[reviewerIdx].key
-	reviewerName := m.DataEntry.Form.GetString(reviewerKey)
-	if strings.TrimSpace(reviewerName) == "" {
-		reviewerName = reviewerKey
+	m.State = STATE_EVALUATION
+	return form.Init()
+}
+
+// formAnswers copies the values of a form into an answerSheet.
+func formAnswers(form *huh.Form, groups []GroupConfig) answerSheet {
+	res := make(answerSheet)
+
+	for _, g := range groups {
+		for _, fc := range g.Fields {
+			key := BuildFieldKey(g.Key, fc.Key)
+			if n := subFieldCount(fc); n > 0 {
+				vs := make([]string, n)
+				for i := range vs {
+					vs[i], _ = form.Get(subFieldKey(key, i)).(string)
+				}
+				res[key] = vs
+				continue
+			}
+			if v := form.Get(key); v != nil {
+				res[key] = v
+			}
+		}
 	}
+	return res
+}
 
-	return reviewerName
+func (m *Model) getReviewerName(reviewerIdx int) string {
+	return m.Evaluation.Reviewers[reviewerIdx].name
 }
 
 func (m *EvaluationModel) getNextReviewerIdx() int {
@@ -107,7 +275,7 @@ func (m *EvaluationModel) getNextReviewerIdx() int {
 	sort.Ints(keys)
 	for _, k := range keys {
 		r := m.Reviewers[k]
-		if r.reviewCompleted {
+		if r.status == REVIEW_DONE || r.status == REVIEW_ABSENT {
 			continue
 		}
 		next = k
@@ -116,9 +284,16 @@ func (m *EvaluationModel) getNextReviewerIdx() int {
 	return next
 }
 
+// reviewerPosition returns the 1-based position of the reviewer in the
+// panel. Reviewers keep their idx when others leave, so it may differ from
+// the idx.
+func (m *EvaluationModel) reviewerPosition(reviewerIdx int) int {
+	return slices.Index(m.reviewerIdxs(), reviewerIdx) + 1
+}
+
 func (m *EvaluationModel) hasPendingReviews() bool {
 	for _, r := range m.Reviewers {
-		if !r.reviewCompleted {
+		if r.status == REVIEW_PENDING || r.status == REVIEW_IN_PROGRESS {
 			return true
 		}
 	}
@@ -133,69 +308,39 @@ func (mainModel *Model) UpdateEvaluationModel(msg tea.Msg) []tea.Cmd {
 		return cmds
 	}
 
+	// esc leaves the form for the dashboard, the review stays in progress
+	if msg, ok := msg.(tea.KeyMsg); ok && msg.String() == "esc" {
+		return append(cmds, mainModel.showDashboard(), tea.ClearScreen)
+	}
+
 	m := &mainModel.Evaluation
-	var cmd tea.Cmd
 
 	if m.Forms[m.ActiveReviewerIdx] == nil {
 		return cmds
 	}
 
-	Form, cmd := m.Forms[m.ActiveReviewerIdx].Update(msg)
-	if f, ok := Form.(*huh.Form); ok {
+	form := m.Forms[m.ActiveReviewerIdx]
+	model, cmd := form.Update(msg)
+	if f, ok := model.(*huh.Form); ok {
+		form.Form = f
 		m.Form = f
 	}
 
-	if m.Forms[m.ActiveReviewerIdx].State == huh.StateCompleted {
+	if form.State == huh.StateCompleted {
 		revIdx := m.ActiveReviewerIdx
-		rev := m.Reviewers[revIdx]
-
-		// collect current evaluation field values into Results with suffix
-		for _, g := range mainModel.Cfg.Evaluation {
-			for _, fc := range g.Fields {
-				k := fc.Key
-				// check for keys specific to the current reviewer
-				// add value to a reviewer specific result key
-				outKey := buildReviewerKey(k, rev)
-				if p, ok := mainModel.Values[k]; ok {
-					switch v := p.(type) {
-					case *string:
-						m.Results[outKey] = *v
-						// reset backing pointer for next run
-						*v = ""
-					case *bool:
-						m.Results[outKey] = *v
-						*v = false
-					case *int:
-						m.Results[outKey] = *v
-						*v = 0
-					case *[]string:
-						m.Results[outKey] = *v
-						*v = nil
-					default:
-						m.Results[outKey] = v
-					}
-				}
-			}
-		}
+
+		// the reviewer's sheet replaces the answers of an earlier run
+		m.Answers[revIdx] = form.values.sheet()
+		dropHidden(mainModel.ceremonyCfg().Evaluation, m.Answers[revIdx], mainModel.DataEntry.Answers)
 
 		// mark reviewer completed in the map
 		if r, ok := m.Reviewers[revIdx]; ok {
-			r.reviewCompleted = true
+			r.status = REVIEW_DONE
 			m.Reviewers[revIdx] = r
 		}
 
-		// find next reviewer without a review
-		next := m.getNextReviewerIdx()
-
-		if next != -1 {
-			m.ActiveReviewerIdx = next
-
-			// initialize the new Form
-			cmds = append(cmds, m.Forms[m.ActiveReviewerIdx].Init())
-		} else {
-			// no more reviewers -> finish
-			mainModel.State = STATE_SUMMARY
-		}
+		cmds = append(cmds, mainModel.showDashboard())
+		mainModel.autosave()
 	} else {
 		cmds = append(cmds, cmd)
 	}
@@ -208,7 +353,7 @@ func (m *Model) ViewEvaluation() (header string, body string, footer string) {
 
 	reviewerName := m.getReviewerName(m.Evaluation.ActiveReviewerIdx)
 
-	header += fmt.Sprintf("Review by %s - %d/%d", s.Highlight.Render(reviewerName), m.Evaluation.ActiveReviewerIdx, len(m.Evaluation.Reviewers))
+	header += fmt.Sprintf("Review by %s - %d/%d", s.Highlight.Render(reviewerName), m.Evaluation.reviewerPosition(m.Evaluation.ActiveReviewerIdx), len(m.Evaluation.Reviewers))
 
 	switch m.Evaluation.Forms[m.Evaluation.ActiveReviewerIdx].State {
 	case huh.StateCompleted:
@@ -223,41 +368,9 @@ func (m *Model) ViewEvaluation() (header string, body string, footer string) {
 			header = m.appErrorBoundaryView(m.errorView(m.Evaluation.Form))
 		}
 
-		const statusWidth = 28
-		sb := strings.Builder{}
-		if m.Evaluation.Results != nil {
-			for k, v := range m.Evaluation.Results {
-				switch t := v.(type) {
-				case string:
-					sb.WriteString(fmt.Sprintf("%s: %s\n", k, t))
-				case bool, int:
-					sb.WriteString(fmt.Sprintf("%s: %v\n", k, t))
-				case []string:
-					sb.WriteString(fmt.Sprintf("%s: %s\n", k, strings.Join(t, ", ")))
-				default:
-					sb.WriteString(fmt.Sprintf("%s: %v\n", k, t))
-				}
-			}
-		}
-
-		statusMarginLeft := m.width - statusWidth - lipgloss.Width(renderedForm) - s.Status.GetMarginRight()
-		status := s.Status.
-			Height(lipgloss.Height(renderedForm)).
-			Width(statusWidth).
-			MarginLeft(statusMarginLeft).
-			Render(s.StatusHeader.Render("Ergebnis") + "\n\n" + sb.String())
-
-		body = lipgloss.JoinHorizontal(lipgloss.Left, renderedForm, status)
-		body = lipgloss.JoinVertical(lipgloss.Top, []string{body}...)
-
-		footer = m.appBoundaryView(m.Evaluation.Form.Help().ShortHelpView(m.Evaluation.Form.KeyBinds()))
-		if len(errors) > 0 {
-			footer = m.appErrorBoundaryView("")
-		}
-
 		body = lipgloss.JoinHorizontal(lipgloss.Left, renderedForm)
 
-		footer = m.appBoundaryView(m.DataEntry.Form.Help().ShortHelpView(m.DataEntry.Form.KeyBinds()))
+		footer = m.appBoundaryView(m.Evaluation.Form.Help().ShortHelpView(m.Evaluation.Form.KeyBinds()) + " · esc Übersicht")
 		if len(errors) > 0 {
 			footer = m.appErrorBoundaryView("")
 		}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/huh"
+)
+
+func init() {
+	logger = log.New(io.Discard, "", 0)
+}
+
+func newTestModel(t *testing.T) Model {
+	t.Helper()
+	APPLICATION_PATH = t.TempDir()
+	return NewModel(defaultConfiguration())
+}
+
+func addReviewers(t *testing.T, m *Model, names ...string) []int {
+	t.Helper()
+	var idxs []int
+	for _, name := range names {
+		idx, err := m.addReviewer(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		idxs = append(idxs, idx)
+	}
+	return idxs
+}
+
+// fillReview sets every text answer of the reviewer's form to value and
+// completes the review.
+func fillReview(t *testing.T, m *Model, reviewerIdx int, value string) {
+	t.Helper()
+	m.openReview(reviewerIdx)
+	form := m.Evaluation.Forms[reviewerIdx]
+	for _, v := range form.values {
+		switch v := v.(type) {
+		case *string:
+			*v = value
+		case []*string:
+			for _, p := range v {
+				*p = value
+			}
+		}
+	}
+	form.State = huh.StateCompleted
+	m.UpdateEvaluationModel(nil)
+	if got := m.Evaluation.Reviewers[reviewerIdx].status; got != REVIEW_DONE {
+		t.Fatalf("reviewer %d: status %v after completing the form", reviewerIdx, got)
+	}
+}
+
+func checkSheet(t *testing.T, sheet answerSheet, value string) {
+	t.Helper()
+	if len(sheet) == 0 {
+		t.Fatal("empty answer sheet")
+	}
+	for key, v := range sheet {
+		switch v := v.(type) {
+		case string:
+			if v != value {
+				t.Errorf("%s = %q, want %q", key, v, value)
+			}
+		case []string:
+			for _, s := range v {
+				if s != value {
+					t.Errorf("%s = %q, want %q", key, v, value)
+				}
+			}
+		}
+	}
+}
+
+func TestEvaluationAnswersAreIsolated(t *testing.T) {
+	m := newTestModel(t)
+	idxs := addReviewers(t, &m, "Anna", "Bernd")
+
+	for i, idx := range idxs {
+		fillReview(t, &m, idx, fmt.Sprintf("value %d", i))
+	}
+	for i, idx := range idxs {
+		// neither the sheet nor the variables of the form were touched by
+		// the other review
+		checkSheet(t, m.Evaluation.Answers[idx], fmt.Sprintf("value %d", i))
+		checkSheet(t, m.Evaluation.Forms[idx].values.sheet(), fmt.Sprintf("value %d", i))
+	}
+
+	// a reopened review works on a copy, the completed sheet is kept until
+	// the review is completed again
+	m.openReview(idxs[0])
+	for _, v := range m.Evaluation.Forms[idxs[0]].values {
+		if p, ok := v.(*string); ok {
+			*p = "changed"
+		}
+	}
+	checkSheet(t, m.Evaluation.Answers[idxs[0]], "value 0")
+	checkSheet(t, m.Evaluation.Answers[idxs[1]], "value 1")
+}
+
+func TestEvaluationSheetsOnlyCompletedReviews(t *testing.T) {
+	m := newTestModel(t)
+	idxs := addReviewers(t, &m, "Anna", "Bernd", "Carla", "Dirk")
+
+	fillReview(t, &m, idxs[0], "a")
+	fillReview(t, &m, idxs[1], "b")
+	fillReview(t, &m, idxs[2], "c")
+	// Carla reopens her review, Bernd leaves, Dirk never starts
+	m.openReview(idxs[2])
+	m.toggleAbsent(idxs[1])
+
+	sheets := m.Evaluation.sheets()
+	if len(sheets) != 1 {
+		t.Fatalf("sheets of reviewers %v, want only %d", sheetIdxs(sheets), idxs[0])
+	}
+	if _, ok := sheets[idxs[0]]; !ok {
+		t.Fatalf("sheets of reviewers %v, want only %d", sheetIdxs(sheets), idxs[0])
+	}
+}
+
+func sheetIdxs(sheets map[int]fieldReader) []int {
+	var res []int
+	for idx := range sheets {
+		res = append(res, idx)
+	}
+	return res
+}
+
+func TestReviewerPosition(t *testing.T) {
+	m := newTestModel(t)
+	idxs := addReviewers(t, &m, "Anna", "Bernd", "Carla")
+	if err := m.removeReviewer(idxs[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	// Carla keeps idx 3 but is the second of the two remaining reviewers
+	if got := m.Evaluation.reviewerPosition(idxs[2]); got != 2 {
+		t.Errorf("position of Carla = %d, want 2", got)
+	}
+	m.openReview(idxs[2])
+	if header, _, _ := m.ViewEvaluation(); !strings.Contains(header, " - 2/2") {
+		t.Errorf("header %q, want position 2/2", header)
+	}
+}
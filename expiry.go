@@ -0,0 +1,246 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// EXPIRY_WARNING_DAYS is how many days before the end of their validity
+// certificates are listed as expiring.
+const EXPIRY_WARNING_DAYS = 30
+
+// validUntil returns the end of the validity of a certificate issued at
+// issued granting the named level, nil if the level doesn't expire.
+func validUntil(levels []SkillLevelConfig, level string, issued time.Time) *time.Time {
+	i := levelIndex(levels, level)
+	if i < 0 || levels[i].ValidMonths == 0 {
+		return nil
+	}
+	t := issued.AddDate(0, levels[i].ValidMonths, 0)
+	return &t
+}
+
+// expiringCertificates returns the certificates which expired or expire
+// within the given number of days, the earliest first. Certificates
+// renewed by a recertification are left out.
+func expiringCertificates(all []CertificateSummary, now time.Time, days int) []CertificateSummary {
+	renewed := make(map[string]bool)
+	for _, s := range all {
+		if s.Recertifies != "" {
+			renewed[s.Recertifies] = true
+		}
+	}
+
+	limit := now.AddDate(0, 0, days)
+	var res []CertificateSummary
+	for _, s := range all {
+		if s.ValidUntil != nil && !renewed[s.ID()] && s.ValidUntil.Before(limit) {
+			res = append(res, s)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].ValidUntil.Before(*res[j].ValidUntil) })
+	return res
+}
+
+// expiryStatus describes the validity of the certificate, empty if it
+// doesn't expire.
+func (s CertificateSummary) expiryStatus(now time.Time) string {
+	if s.ValidUntil == nil {
+		return ""
+	}
+	until := s.ValidUntil.Format("02.01.2006")
+	if s.ValidUntil.Before(now) {
+		return fmt.Sprintf("abgelaufen seit %s", until)
+	}
+	days := int(s.ValidUntil.Sub(now).Hours() / 24)
+	if days == 1 {
+		return fmt.Sprintf("gültig bis %s (noch 1 Tag)", until)
+	}
+	return fmt.Sprintf("gültig bis %s (noch %d Tage)", until, days)
+}
+
+// certificateImage returns the copy of the object image stored next to the
+// certificate, empty if there is none.
+func certificateImage(s CertificateSummary) string {
+	matches, _ := filepath.Glob(strings.TrimSuffix(s.Path, filepath.Ext(s.Path)) + ".*")
+	for _, p := range matches {
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".yaml", ".html", ".pdf":
+			continue
+		}
+		return p
+	}
+	return ""
+}
+
+// runExpiring implements the `expiring` command.
+func runExpiring(cfg Configuration, args []string) error {
+	flags := flag.NewFlagSet("expiring", flag.ContinueOnError)
+	days := flags.Int("days", EXPIRY_WARNING_DAYS, "list certificates expiring within this many days")
+	var filter CertificateFilter
+	buildTrack := trackFlag(cfg, flags)
+	if _, err := parseArgs(flags, args); err != nil {
+		return err
+	}
+	if err := buildTrack(&filter); err != nil {
+		return err
+	}
+
+	all, err := findLatestCertificates(0)
+	if err != nil {
+		return err
+	}
+	all = filterCertificates(all, filter)
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tANTRAGSTELLER\tOBJEKT\tRANG\tSTATUS")
+	for _, s := range expiringCertificates(all, now, *days) {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.ID(), s.Applicant, s.ObjectName, s.Rank, s.expiryStatus(now))
+	}
+	return w.Flush()
+}
+
+// ExpiryModel holds the state of the list of expiring certificates.
+type ExpiryModel struct {
+	List  []CertificateSummary
+	Index int
+}
+
+func (m *Model) InitExpiryModel() {
+	all, err := findLatestCertificates(0)
+	if err != nil {
+		logger.Printf("Failed to load certificate list: %v", err)
+	}
+	m.Expiry = ExpiryModel{List: expiringCertificates(all, time.Now(), EXPIRY_WARNING_DAYS)}
+}
+
+func (m *Model) UpdateExpiryModel(msg tea.Msg) []tea.Cmd {
+	cmds := []tea.Cmd{}
+
+	if m.State != STATE_EXPIRY {
+		return cmds
+	}
+
+	e := &m.Expiry
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc", "q":
+			m.State = STATE_MENU
+			cmds = append(cmds, tea.ClearScreen)
+		case "up", "k":
+			if e.Index > 0 {
+				e.Index--
+			}
+		case "down", "j":
+			if e.Index < len(e.List)-1 {
+				e.Index++
+			}
+		case "enter":
+			if len(e.List) == 0 {
+				break
+			}
+			cmds = append(cmds, m.startRecertification(e.List[e.Index]), tea.ClearScreen)
+		}
+	}
+
+	return cmds
+}
+
+// startRecertification starts a new ceremony renewing the given
+// certificate, the data entry is prefilled with its applicant, object,
+// object image and track.
+func (m *Model) startRecertification(s CertificateSummary) tea.Cmd {
+	removeDraft()
+	m.resetCeremony()
+	m.startedAt = time.Now()
+	m.recertifies = s.ID()
+
+	initial := make(answerSheet)
+	if key := m.Cfg.DataKey(ROLE_APPLICANT); key != "" {
+		initial[key] = s.Applicant
+	}
+	if key := m.Cfg.DataKey(ROLE_OBJECT); key != "" {
+		initial[key] = s.ObjectName
+	}
+	if key := m.Cfg.DataKey(ROLE_IMAGE); key != "" {
+		if img := certificateImage(s); img != "" {
+			initial[key] = img
+		}
+	}
+	if option := m.Cfg.trackOption(s.Track); option != "" {
+		initial[m.Cfg.DataKey(ROLE_TRACK)] = option
+	}
+
+	m.DataEntry.Answers = initial
+	m.DataEntry.Form = m.newDataEntryForm(initial)
+	m.State = STATE_DATA_ENTRY
+	return m.DataEntry.Form.Init()
+}
+
+// recertificationError checks that the running ceremony may renew the
+// certificate it recertifies, see checkRecertification. The error is meant
+// for the host, the details are logged.
+func (m *Model) recertificationError() error {
+	if m.recertifies == "" {
+		return nil
+	}
+	renewed, err := findCertificate(m.recertifies)
+	if err != nil {
+		logger.Printf("Failed to find renewed certificate %s: %v", m.recertifies, err)
+		return fmt.Errorf("Das zu erneuernde Zertifikat %s wurde nicht gefunden.", m.recertifies)
+	}
+
+	cfg := m.ceremonyCfg()
+	if err := checkRecertification(cfg, renewed, m.DataEntry.Answers.GetString(m.Cfg.DataKey(ROLE_APPLICANT))); err != nil {
+		logger.Printf("Refusing recertification: %v", err)
+		if len(cfg.Tracks) > 0 {
+			return fmt.Errorf("Zertifikat %s gehört zu %s auf der Laufbahn %s und kann nur für diese Person auf dieser Laufbahn erneuert werden.",
+				renewed.ID(), renewed.Applicant, cfg.TrackOf(renewed.Track).Title())
+		}
+		return fmt.Errorf("Zertifikat %s gehört zu %s und kann nur für diese Person erneuert werden.", renewed.ID(), renewed.Applicant)
+	}
+	return nil
+}
+
+func (m *Model) ViewExpiry() (string, string, string) {
+	s := m.Styles
+	e := m.Expiry
+
+	header := "Ablaufende Zertifikate"
+
+	if len(e.List) == 0 {
+		body := fmt.Sprintf("\nKeine abgelaufenen oder in den nächsten %d Tagen ablaufenden Zertifikate.\n", EXPIRY_WARNING_DAYS)
+		footer := m.appBoundaryView("Drücken Sie 'esc' oder 'q' zum Zurückkehren")
+		return header, body, footer
+	}
+
+	var b strings.Builder
+	b.WriteString("\n")
+
+	now := time.Now()
+	for i, c := range e.List {
+		label := fmt.Sprintf("%s (%s), %s: %s", c.Applicant, c.ObjectName, c.Rank, c.expiryStatus(now))
+		if len(m.Cfg.Tracks) > 0 {
+			label = fmt.Sprintf("%s (%s), %s %s: %s", c.Applicant, c.ObjectName, m.Cfg.TrackOf(c.Track).Title(), c.Rank, c.expiryStatus(now))
+		}
+		label = truncate(label, max(m.width, printListWidth)-2)
+		if i == e.Index {
+			fmt.Fprintf(&b, "> %s\n", s.Highlight.Render(label))
+		} else {
+			fmt.Fprintf(&b, "  %s\n", label)
+		}
+	}
+
+	footer := m.appBoundaryView("↑/↓ Auswahl · Enter Rezertifizierung starten · Esc Zurück")
+	return header, b.String(), footer
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/google/uuid"
+)
+
+// completeDataEntryAs completes the data entry of m with the given applicant.
+func completeDataEntryAs(m *Model, applicant string) {
+	m.State = STATE_DATA_ENTRY
+	m.DataEntry.Answers[m.Cfg.DataKey(ROLE_APPLICANT)] = applicant
+	m.DataEntry.Form.State = huh.StateCompleted
+	m.UpdateDataEntryModel(nil)
+}
+
+func TestRecertificationKeepsApplicant(t *testing.T) {
+	m := newTestModel(t)
+	cert := Certificate{ID: uuid.New(), Date: time.Now(), Applicant: "Erika Mustermann", ObjectName: "Kirschtorte"}
+	if _, err := storeCertificate(cert, "", m.Cfg); err != nil {
+		t.Fatal(err)
+	}
+	renewed, err := findCertificate(cert.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.startRecertification(renewed)
+
+	completeDataEntryAs(&m, "Max Mustermann")
+	if m.State != STATE_DATA_ENTRY || m.DataEntry.Error == "" {
+		t.Fatalf("data entry for another applicant accepted, state %v", m.State)
+	}
+
+	// a resumed draft skips the data entry, the certificate is checked
+	// again before it is issued
+	m.State = STATE_SUMMARY
+	m.issueCertificate()
+	if m.State != STATE_SUMMARY || m.Summary.Error == "" {
+		t.Fatalf("certificate for another applicant issued, state %v", m.State)
+	}
+
+	completeDataEntryAs(&m, "Erika Mustermann")
+	if m.State != STATE_DASHBOARD || m.DataEntry.Error != "" {
+		t.Fatalf("data entry for the applicant rejected: %s", m.DataEntry.Error)
+	}
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A formula computes the total score from the group results on the scale
+// of the total, e.g. `0.4*taste + 0.2*appearance + max(smell, innovation)`.
+// It knows numbers, the group keys, + - * /, comparisons, parentheses and
+// the functions min(), max() and if(condition, then, else). Comparisons are
+// 1 if true and 0 otherwise, a division by zero is 0. Formulas are parsed
+// once when the configuration is loaded, evaluating them cannot fail.
+type formula interface {
+	eval(vars map[string]float64) float64
+}
+
+type numberNode float64
+
+type varNode struct {
+	name string
+	pos  int
+}
+
+type negNode struct {
+	x formula
+}
+
+type binaryNode struct {
+	op   string
+	l, r formula
+}
+
+type callNode struct {
+	name string
+	args []formula
+}
+
+func (n numberNode) eval(map[string]float64) float64 { return float64(n) }
+
+func (n varNode) eval(vars map[string]float64) float64 { return vars[n.name] }
+
+func (n negNode) eval(vars map[string]float64) float64 { return -n.x.eval(vars) }
+
+func (n binaryNode) eval(vars map[string]float64) float64 {
+	l, r := n.l.eval(vars), n.r.eval(vars)
+	switch n.op {
+	case "+":
+		return l + r
+	case "-":
+		return l - r
+	case "*":
+		return l * r
+	case "/":
+		if r == 0 {
+			return 0
+		}
+		return l / r
+	case "<":
+		return truth(l < r)
+	case "<=":
+		return truth(l <= r)
+	case ">":
+		return truth(l > r)
+	case ">=":
+		return truth(l >= r)
+	case "==":
+		return truth(l == r)
+	case "!=":
+		return truth(l != r)
+	}
+	return 0
+}
+
+func (n callNode) eval(vars map[string]float64) float64 {
+	switch n.name {
+	case "if":
+		if n.args[0].eval(vars) != 0 {
+			return n.args[1].eval(vars)
+		}
+		return n.args[2].eval(vars)
+	case "min", "max":
+		res := n.args[0].eval(vars)
+		for _, a := range n.args[1:] {
+			if n.name == "min" {
+				res = math.Min(res, a.eval(vars))
+			} else {
+				res = math.Max(res, a.eval(vars))
+			}
+		}
+		return res
+	}
+	return 0
+}
+
+// formulaVars returns the group references of f in order.
+func formulaVars(f formula) []varNode {
+	switch n := f.(type) {
+	case varNode:
+		return []varNode{n}
+	case negNode:
+		return formulaVars(n.x)
+	case binaryNode:
+		return append(formulaVars(n.l), formulaVars(n.r)...)
+	case callNode:
+		var res []varNode
+		for _, a := range n.args {
+			res = append(res, formulaVars(a)...)
+		}
+		return res
+	}
+	return nil
+}
+
+// checkFormulaGroups checks that f only refers to scored groups which are
+// always asked. A group hidden by its show_if conditions has no result, and
+// no value would stand in for it in every formula.
+func checkFormulaGroups(f formula, groups []GroupConfig) error {
+	for _, v := range formulaVars(f) {
+		i := slices.IndexFunc(groups, func(g GroupConfig) bool { return g.Key == v.name })
+		if i < 0 || groups[i].FieldKey(ROLE_SCORE) == "" {
+			return fmt.Errorf("unknown group %q at position %d", v.name, v.pos)
+		}
+		if len(groups[i].ShowIf) > 0 {
+			return fmt.Errorf("group %q at position %d has show_if conditions, a formula can only use groups which are always asked", v.name, v.pos)
+		}
+	}
+	return nil
+}
+
+func truth(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type formulaToken struct {
+	text string
+	pos  int // 1-based column
+}
+
+// tokenizeFormula splits src into numbers, names, operators and
+// parentheses.
+func tokenizeFormula(src string) ([]formulaToken, error) {
+	var tokens []formulaToken
+	runes := []rune(src)
+
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(c):
+			i++
+			continue
+		case unicode.IsDigit(c) || c == '.':
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+		case unicode.IsLetter(c) || c == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+		case strings.ContainsRune("<>=!", c):
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				i++
+			}
+			if op := string(runes[start:i]); op == "=" || op == "!" {
+				return nil, fmt.Errorf("unexpected %q at position %d", op, start+1)
+			}
+		case strings.ContainsRune("+-*/(),", c):
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected %q at position %d", string(c), start+1)
+		}
+		tokens = append(tokens, formulaToken{text: string(runes[start:i]), pos: start + 1})
+	}
+	return tokens, nil
+}
+
+type formulaParser struct {
+	tokens []formulaToken
+	i      int
+	end    int
+}
+
+// parseFormula parses src. The group names are checked against a
+// configuration by checkFormulaGroups.
+func parseFormula(src string) (formula, error) {
+	tokens, err := tokenizeFormula(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := formulaParser{tokens: tokens, end: len([]rune(src)) + 1}
+
+	f, err := p.comparison()
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+	}
+	return f, nil
+}
+
+func (p *formulaParser) peek() (formulaToken, bool) {
+	if p.i >= len(p.tokens) {
+		return formulaToken{}, false
+	}
+	return p.tokens[p.i], true
+}
+
+func (p *formulaParser) next() (formulaToken, error) {
+	t, ok := p.peek()
+	if !ok {
+		return t, fmt.Errorf("unexpected end of formula at position %d", p.end)
+	}
+	p.i++
+	return t, nil
+}
+
+func (p *formulaParser) expect(text string) error {
+	t, err := p.next()
+	if err != nil {
+		return err
+	}
+	if t.text != text {
+		return fmt.Errorf("expected %q at position %d, got %q", text, t.pos, t.text)
+	}
+	return nil
+}
+
+func (p *formulaParser) comparison() (formula, error) {
+	l, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := p.peek(); ok {
+		switch t.text {
+		case "<", "<=", ">", ">=", "==", "!=":
+			p.i++
+			r, err := p.additive()
+			if err != nil {
+				return nil, err
+			}
+			return binaryNode{op: t.text, l: l, r: r}, nil
+		}
+	}
+	return l, nil
+}
+
+func (p *formulaParser) additive() (formula, error) {
+	l, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.peek()
+		if !ok || (t.text != "+" && t.text != "-") {
+			return l, nil
+		}
+		p.i++
+		r, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		l = binaryNode{op: t.text, l: l, r: r}
+	}
+}
+
+func (p *formulaParser) term() (formula, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.peek()
+		if !ok || (t.text != "*" && t.text != "/") {
+			return l, nil
+		}
+		p.i++
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l = binaryNode{op: t.text, l: l, r: r}
+	}
+}
+
+func (p *formulaParser) unary() (formula, error) {
+	if t, ok := p.peek(); ok && t.text == "-" {
+		p.i++
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return negNode{x: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *formulaParser) primary() (formula, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	c := []rune(t.text)[0]
+	switch {
+	case t.text == "(":
+		f, err := p.comparison()
+		if err != nil {
+			return nil, err
+		}
+		return f, p.expect(")")
+	case unicode.IsDigit(c) || c == '.':
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", t.text, t.pos)
+		}
+		return numberNode(v), nil
+	case unicode.IsLetter(c) || c == '_':
+		if n, ok := p.peek(); ok && n.text == "(" {
+			return p.call(t)
+		}
+		return varNode{name: t.text, pos: t.pos}, nil
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+}
+
+func (p *formulaParser) call(name formulaToken) (formula, error) {
+	p.i++ // (
+
+	var args []formula
+	if t, ok := p.peek(); !ok || t.text != ")" {
+		for {
+			a, err := p.comparison()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, a)
+			if t, ok := p.peek(); ok && t.text == "," {
+				p.i++
+				continue
+			}
+			break
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	switch name.text {
+	case "if":
+		if len(args) != 3 {
+			return nil, fmt.Errorf("if() at position %d takes 3 arguments, got %d", name.pos, len(args))
+		}
+	case "min", "max":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%s() at position %d takes at least 1 argument", name.text, name.pos)
+		}
+	default:
+		return nil, fmt.Errorf("unknown function %q at position %d", name.text, name.pos)
+	}
+	return callNode{name: name.text, args: args}, nil
+}
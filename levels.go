@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// checkLevels walks the skill levels in configured order and records for
+// each level whether its rules are met. A level is only reached if all
+// levels before it are reached as well, so the first level not reached
+// tells which rules blocked the next level.
+//
+// standing is the applicant's career from earlier certificates. It is nil
+// when that is not known, e.g. when recomputing the score of an old
+// certificate; rules about earlier certificates are not checked then.
+func (b *ScoreBreakdown) checkLevels(levels []SkillLevelConfig, standing *Standing, now time.Time) {
+	b.Levels = nil
+	b.Level = ""
+	b.Performance = ""
+
+	// the performance only takes the rules of this ceremony into account,
+	// it is what later ceremonies count towards a progression
+	performed := -1
+	for i, level := range levels {
+		if len(b.levelBlockers(level)) > 0 {
+			break
+		}
+		performed = i
+		b.Performance = level.Name
+	}
+
+	blocked := ""
+	for i, level := range levels {
+		check := LevelCheck{
+			Level:     level.Level,
+			Name:      level.Name,
+			MinPoints: float64(level.MinPoints),
+			Blockers:  b.levelBlockers(level),
+		}
+		if standing != nil {
+			check.Blockers = append(check.Blockers, check.careerBlockers(level, levels, i, performed >= i, *standing, now)...)
+		}
+		if blocked != "" {
+			check.Blockers = append([]string{fmt.Sprintf("%s nicht erreicht", blocked)}, check.Blockers...)
+		}
+
+		check.Met = len(check.Blockers) == 0
+		if check.Met {
+			b.Level = level.Name
+		} else if blocked == "" {
+			blocked = level.Name
+		}
+		b.Levels = append(b.Levels, check)
+	}
+}
+
+// levelBlockers returns a description of every rule of level which the
+// ratings of the ceremony don't meet.
+func (b *ScoreBreakdown) levelBlockers(level SkillLevelConfig) []string {
+	var res []string
+
+	if b.Total < float64(level.MinPoints) {
+		res = append(res, fmt.Sprintf("Gesamtwertung %.2f unter %.2f", b.Total, level.MinPoints))
+	}
+
+	for _, g := range b.Groups {
+		if min, ok := level.MinGroupScores[g.Key]; ok && g.Avg < float64(min) {
+			res = append(res, fmt.Sprintf("%s %.2f unter %.2f", g.Title, g.Avg, min))
+		}
+	}
+
+	if level.MinRating > 0 {
+		// ratings are compared on the scale of the total like min_points
+		count := 0
+		var lowest ReviewerValue
+		var lowestValue float64
+		var lowestGroup string
+		for _, g := range b.Groups {
+			for _, v := range g.Values {
+				scaled := g.Scale.Normalize(v.Value, b.Scale)
+				if scaled >= level.MinRating {
+					continue
+				}
+				if count == 0 || scaled < lowestValue {
+					lowest, lowestValue, lowestGroup = v, scaled, g.Title
+				}
+				count++
+			}
+		}
+		switch {
+		case count == 1:
+			res = append(res, fmt.Sprintf("%s bewertet %s mit %s, mindestens %s erforderlich", lowest.Name, lowestGroup, formatRating(lowest.Value), formatRating(level.MinRating)))
+		case count > 1:
+			res = append(res, fmt.Sprintf("%d Einzelwertungen unter %s, die niedrigste: %s bewertet %s mit %s", count, formatRating(level.MinRating), lowest.Name, lowestGroup, formatRating(lowest.Value)))
+		}
+	}
+
+	if level.MinReviewers > 0 && len(b.Reviewers) < level.MinReviewers {
+		res = append(res, fmt.Sprintf("%d Zertifizierer, mindestens %d erforderlich", len(b.Reviewers), level.MinReviewers))
+	}
+
+	return res
+}
+
+// careerBlockers returns a description of every rule of level about
+// earlier certificates which the applicant doesn't meet. The level at
+// index i of levels was performed in this ceremony if performed is set. An
+// applicant already holding the level doesn't need to earn it again.
+func (check *LevelCheck) careerBlockers(level SkillLevelConfig, levels []SkillLevelConfig, i int, performed bool, standing Standing, now time.Time) []string {
+	var res []string
+
+	held := standing.held(levels)
+	if level.RequiresLevel != nil && held < *level.RequiresLevel {
+		name := fmt.Sprint(*level.RequiresLevel)
+		for _, l := range levels {
+			if l.Level == *level.RequiresLevel {
+				name = l.Name
+			}
+		}
+		res = append(res, fmt.Sprintf("erfordert ein früheres Zertifikat mit %s", name))
+	}
+
+	if p := level.Progression; p != nil && held < level.Level {
+		var since time.Time
+		if p.WithinMonths > 0 {
+			since = now.AddDate(0, -p.WithinMonths, 0)
+		}
+		count := standing.performedSince(levels, i, since)
+		if performed {
+			count++
+		}
+		if count < p.Certificates {
+			check.Remaining = p.Certificates - count
+			res = append(res, progressText(check.Remaining, count, *p))
+		}
+	}
+
+	return res
+}
+
+// progressText describes how many ceremonies are missing for a
+// progression, e.g. "noch 1 Zeremonie bis zum nächsten Level".
+func progressText(remaining, count int, p ProgressionRule) string {
+	ceremonies := "Zeremonien"
+	if remaining == 1 {
+		ceremonies = "Zeremonie"
+	}
+	res := fmt.Sprintf("noch %d %s bis zum nächsten Level (%d von %d", remaining, ceremonies, count, p.Certificates)
+	if p.WithinMonths > 0 {
+		res += fmt.Sprintf(" innerhalb von %d Monaten", p.WithinMonths)
+	}
+	return res + ")"
+}
+
+// NextLevel returns the first level not reached, nil if all are reached.
+func (b ScoreBreakdown) NextLevel() *LevelCheck {
+	for i := range b.Levels {
+		if !b.Levels[i].Met {
+			return &b.Levels[i]
+		}
+	}
+	return nil
+}
+
+// renumberLevels gives levels sharing the number of an earlier level the
+// next free number, as configurations of older versions listed two levels
+// with number 4. It returns the names of the renumbered levels.
+func renumberLevels(levels []SkillLevelConfig) []string {
+	var renumbered []string
+	used := make(map[int]bool)
+	highest := 0
+	for i, l := range levels {
+		if used[l.Level] {
+			levels[i].Level = highest + 1
+			renumbered = append(renumbered, fmt.Sprintf("%q %d -> %d", l.Name, l.Level, levels[i].Level))
+		}
+		used[levels[i].Level] = true
+		if i == 0 || levels[i].Level > highest {
+			highest = levels[i].Level
+		}
+	}
+	return renumbered
+}
+
+// applyLegacyLevels renumbers duplicate level numbers of all tracks, see
+// renumberLevels.
+func (c *Configuration) applyLegacyLevels() {
+	renumbered := renumberLevels(c.SkillLevels)
+	for _, t := range c.Tracks {
+		renumbered = append(renumbered, renumberLevels(t.SkillLevels)...)
+	}
+	if len(renumbered) > 0 {
+		logger.Printf("Warning: skill levels share their level number, renumbered %s. Update the level numbers in the configuration to silence this warning.",
+			strings.Join(renumbered, ", "))
+	}
+}
+
+// validateLevels checks the order of the skill levels and their rules
+// against the evaluation groups. Levels are listed with rising numbers and
+// don't require fewer points than the levels before them.
+func (c Configuration) validateLevels() error {
+	numbers := make(map[int]bool)
+	for i, l := range c.SkillLevels {
+		where := fmt.Sprintf("skilllevels %q", l.Name)
+		if numbers[l.Level] {
+			return l.pos.errorf("%s: level %d is used by more than one level", where, l.Level)
+		}
+		numbers[l.Level] = true
+		if i == 0 {
+			continue
+		}
+		prev := c.SkillLevels[i-1]
+		if l.Level < prev.Level {
+			return l.pos.errorf("%s: level %d is lower than level %d of %q before it", where, l.Level, prev.Level, prev.Name)
+		}
+		if l.MinPoints < prev.MinPoints {
+			return l.pos.errorf("%s: min_points %s is lower than %s of %q before it", where,
+				formatRating(float64(l.MinPoints)), formatRating(float64(prev.MinPoints)), prev.Name)
+		}
+	}
+
+	scored := make(map[string]bool)
+	for _, key := range scoredGroupKeys(c.Evaluation) {
+		scored[key] = true
+	}
+
+	for _, l := range c.SkillLevels {
+		if err := l.validate(scored, numbers); err != nil {
+			return l.pos.wrap(fmt.Errorf("skilllevels %q: %w", l.Name, err))
+		}
+	}
+	return nil
+}
+
+// validate checks the rules of the level, scored holds the keys of the
+// evaluation groups with a score field and numbers the level numbers.
+func (l SkillLevelConfig) validate(scored map[string]bool, numbers map[int]bool) error {
+	for key := range l.MinGroupScores {
+		if !scored[key] {
+			return fmt.Errorf("min_group_scores: %q is no evaluation group with a score field", key)
+		}
+	}
+	if l.MinRating < 0 || l.MinReviewers < 0 || l.ValidMonths < 0 {
+		return fmt.Errorf("min_rating, min_reviewers and valid_months must not be negative")
+	}
+	if p := l.Progression; p != nil && (p.Certificates < 1 || p.WithinMonths < 0) {
+		return fmt.Errorf("progression needs at least 1 certificate and within_months must not be negative")
+	}
+	if l.RequiresLevel != nil && !numbers[*l.RequiresLevel] {
+		return fmt.Errorf("requires_level %d does not exist", *l.RequiresLevel)
+	}
+	return nil
+}
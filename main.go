@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
@@ -39,27 +39,29 @@ type Model struct {
 	applicantName string
 	objectName    string
 	objectImage   string
+	startedAt     time.Time
+	// applicantStanding is the applicant's career from earlier certificates.
+	applicantStanding Standing
+	// recertifies is the ID of the certificate renewed by the ceremony.
+	recertifies string
 
 	Cfg        Configuration
 	DataEntry  DataEntryModel
 	Evaluation EvaluationModel
 	Summary    SummaryModel
 
-	// Values holds pointers to the backing variables for each field keyed by field key.
-	Values map[string]any
+	// Roster holds the known reviewers, it is reloaded for every ceremony.
+	Roster Roster
+
 	// Menu state is embedded (defined in menu.go)
 	Menu MenuState
 	// Print view
-	PrintIndex int
-	PrintList  []CertificateSummary
-}
-
-func (m Model) GetString(key string) string {
-	v, ok := m.Values[key].(string)
-	if !ok {
-		return ""
-	}
-	return v
+	Print PrintModel
+	// Expiry lists the expired and expiring certificates
+	Expiry ExpiryModel
+	// ConfirmAbort is set while asking whether to abort the running
+	// ceremony during data entry or evaluation.
+	ConfirmAbort bool
 }
 
 func BuildFieldKey(groupKey, fieldKey string) string {
@@ -69,81 +71,189 @@ func BuildFieldKey(groupKey, fieldKey string) string {
 func NewModel(cfg Configuration) Model {
 
 	m := Model{
-		Cfg:    cfg,
-		Lg:     lipgloss.DefaultRenderer(),
-		State:  STATE_MENU,
-		Values: make(map[string]any),
-		width:  maxWidth,
+		Cfg:   cfg,
+		Lg:    lipgloss.DefaultRenderer(),
+		State: STATE_MENU,
+		width: maxWidth,
 	}
 
 	m.Styles = NewStyles(m.Lg)
 
 	m.InitMenuModel()
+	m.resetCeremony()
+
+	return m
+}
+
+// resetCeremony discards all values entered for the current ceremony and
+// prepares fresh data entry, evaluation and summary models for the next one.
+func (m *Model) resetCeremony() {
+	m.applicantName = ""
+	m.objectName = ""
+	m.objectImage = ""
+	m.startedAt = time.Time{}
+	m.applicantStanding = Standing{}
+	m.recertifies = ""
+	m.ConfirmAbort = false
+
+	roster, err := loadRoster()
+	if err != nil {
+		logger.Printf("Failed to load reviewer roster: %v", err)
+	}
+	m.Roster = roster
+
 	m.InitDataEntryModel()
 	m.InitEvaluationModel()
 	m.InitSummaryModel()
+}
 
-	return m
+// rangeOptions returns the options offered by "range" fields, one per
+// value of the field's scale.
+func rangeOptions(fc FieldConfig) []huh.Option[string] {
+	scale := fc.RatingScale()
+	var res []huh.Option[string]
+	for _, v := range scale.Values() {
+		res = append(res, huh.NewOption[string](scale.Format(v), formatRating(v)))
+	}
+	return res
+}
+
+// fieldBindings holds pointers to the variables the fields of a single form
+// write to, keyed by field key.
+type fieldBindings map[string]any
+
+// sheet copies the current values of the bound variables into a new
+// answerSheet.
+func (b fieldBindings) sheet() answerSheet {
+	res := make(answerSheet, len(b))
+	for k := range b {
+		res[k] = b.Get(k)
+	}
+	return res
+}
+
+// Get returns a copy of the current value of the variable bound to key, so
+// validators can look at the other fields of the form.
+func (b fieldBindings) Get(key string) any {
+	switch v := b[key].(type) {
+	case *string:
+		return *v
+	case *bool:
+		return *v
+	case *[]string:
+		return append([]string(nil), *v...)
+	case []*string:
+		vs := make([]string, len(v))
+		for i, p := range v {
+			vs[i] = *p
+		}
+		return vs
+	}
+	return nil
+}
+
+func (b fieldBindings) GetString(key string) string {
+	s, _ := b.Get(key).(string)
+	return s
+}
+
+// subFieldKey returns the key of the i-th form field of a field shown as
+// several form fields, i.e. ranking and likert fields.
+func subFieldKey(fcKey string, i int) string {
+	return fmt.Sprintf("%s#%d", fcKey, i+1)
+}
+
+// subFieldCount returns the number of form fields a ranking or likert field
+// is shown as, 0 for fields shown as a single form field.
+func subFieldCount(fc FieldConfig) int {
+	switch fc.Type {
+	case "ranking":
+		return len(fc.Options)
+	case "likert":
+		return len(fc.Statements)
+	}
+	return 0
 }
 
-// buildGroups constructs huh.Groups from GroupConfig entries. If reviewers
-// are provided and a group's name is "Wertung" it will expand that group into
-// one per reviewer, suffixing keys with the reviewer key to avoid collisions.
-func (m *Model) buildGroups(groupCfgs []GroupConfig) []*huh.Group {
+// buildGroups constructs huh.Groups from GroupConfig entries. Fields are
+// prefilled from initial (keyed by field key) which may be nil. Every call
+// binds the fields to new variables, returned keyed by field key, so forms
+// built from the same groups never share values. The show_if conditions of
+// groups and fields are evaluated on these values and earlier, the answers
+// of the forms filled in before, which may be nil.
+func (m *Model) buildGroups(groupCfgs []GroupConfig, initial answerSheet, earlier fieldReader) ([]*huh.Group, fieldBindings) {
 	var res []*huh.Group
+	values := make(fieldBindings)
+	form := layeredReader{values, earlier}
 
 	for _, gcfg := range groupCfgs {
 		groupKey := gcfg.Key
-		// Default handling for non-Wertung groups (or Wertung when no reviewers)
+		// huh can only hide whole groups, so a conditional field gets a
+		// group of its own, continued by the fields following it
 		var fields []huh.Field
+		var shown func() bool
+		flush := func() {
+			if len(fields) == 0 {
+				return
+			}
+			group := huh.NewGroup(fields...).
+				Title(gcfg.Title).
+				Description(gcfg.Description + "\n")
+			if shown != nil {
+				visible := shown
+				group = group.WithHideFunc(func() bool { return !visible() })
+			}
+			res = append(res, group)
+			fields = nil
+		}
+		conditional := false
 		for _, fc := range gcfg.Fields {
+			if len(fc.ShowIf) > 0 || conditional {
+				flush()
+			}
+			conditional = len(fc.ShowIf) > 0
+			switch {
+			case conditional:
+				shown = func() bool { return gcfg.shown(fc, form) }
+			case len(gcfg.ShowIf) > 0:
+				shown = func() bool { return gcfg.ShowIf.hold(groupKey, form) }
+			default:
+				shown = nil
+			}
+
 			fcKey := BuildFieldKey(groupKey, fc.Key)
 			switch fc.Type {
 			case "range":
-				v := ""
-				m.Values[fc.Key] = &v
+				v := initial.GetString(fcKey)
+				values[fcKey] = &v
 				sel := huh.NewSelect[string]().
 					Key(fcKey).
 					Value(&v).
 					Title(fc.Title).
 					Description(fc.Description)
-				sel = sel.Options(
-					huh.NewOption[string]("", "0"),
-					huh.NewOption[string]("⭐", "1"),
-					huh.NewOption[string]("⭐⭐", "2"),
-					huh.NewOption[string]("⭐⭐⭐", "3"),
-					huh.NewOption[string]("⭐⭐⭐⭐", "4"),
-					huh.NewOption[string]("⭐⭐⭐⭐⭐", "5"),
-				)
-				if fc.Mandatory {
-					sel = sel.Validate(func(s string) error {
-						if strings.TrimSpace(s) == "" {
-							return fmt.Errorf("%s is required", fc.Title)
-						}
-						return nil
-					})
+				sel = sel.Options(rangeOptions(fc)...)
+				if validate := stringValidator(fc, groupKey, values); validate != nil {
+					sel = sel.Validate(validate)
 				}
 				fields = append(fields, sel)
 			case "input":
-				v := ""
-				m.Values[fc.Key] = &v
+				v := initial.GetString(fcKey)
+				values[fcKey] = &v
 				inp := huh.NewInput().
 					Key(fcKey).
 					Value(&v).
 					Title(fc.Title).
 					Description(fc.Description)
-				if fc.Mandatory {
-					inp = inp.Validate(func(s string) error {
-						if strings.TrimSpace(s) == "" {
-							return fmt.Errorf("%s is required", fc.Title)
-						}
-						return nil
-					})
+				if fc.Role == ROLE_REVIEWER {
+					inp = inp.Suggestions(m.Roster.Suggestions())
+				}
+				if validate := stringValidator(fc, groupKey, values); validate != nil {
+					inp = inp.Validate(validate)
 				}
 				fields = append(fields, inp)
 			case "select":
-				v := ""
-				m.Values[fc.Key] = &v
+				v := initial.GetString(fcKey)
+				values[fcKey] = &v
 				sel := huh.NewSelect[string]().
 					Key(fcKey).
 					Value(&v).
@@ -152,55 +262,40 @@ func (m *Model) buildGroups(groupCfgs []GroupConfig) []*huh.Group {
 				if len(fc.Options) > 0 {
 					sel = sel.Options(huh.NewOptions[string](fc.Options...)...)
 				}
-				if fc.Mandatory {
-					sel = sel.Validate(func(s string) error {
-						if strings.TrimSpace(s) == "" {
-							return fmt.Errorf("%s is required", fc.Title)
-						}
-						return nil
-					})
+				if validate := stringValidator(fc, groupKey, values); validate != nil {
+					sel = sel.Validate(validate)
 				}
 				fields = append(fields, sel)
 			case "text":
-				v := ""
-				m.Values[fc.Key] = &v
+				v := initial.GetString(fcKey)
+				values[fcKey] = &v
 				txt := huh.NewText().
 					Key(fcKey).
 					Value(&v).
 					Title(fc.Title).
 					Description(fc.Description)
-				if fc.Mandatory {
-					txt = txt.Validate(func(s string) error {
-						if strings.TrimSpace(s) == "" {
-							return fmt.Errorf("%s is required", fc.Title)
-						}
-						return nil
-					})
+				if validate := stringValidator(fc, groupKey, values); validate != nil {
+					txt = txt.Validate(validate)
 				}
 				fields = append(fields, txt)
 			case "filepicker":
-				v := ""
-				m.Values[fc.Key] = &v
+				v := initial.GetString(fcKey)
+				values[fcKey] = &v
 				fp := huh.NewFilePicker().
 					Key(fcKey).
 					Value(&v).
 					Title(fc.Title).
 					Description(fc.Description).
 					AllowedTypes(fc.Options)
-				if fc.Mandatory {
-					fp = fp.Validate(func(s string) error {
-						if strings.TrimSpace(s) == "" {
-							return fmt.Errorf("%s is required", fc.Title)
-						}
-						return nil
-					})
+				if validate := stringValidator(fc, groupKey, values); validate != nil {
+					fp = fp.Validate(validate)
 				}
 				fields = append(fields, fp)
 			case "confirm":
-				b := false
-				m.Values[fc.Key] = &b
+				b, _ := initial[fcKey].(bool)
+				values[fcKey] = &b
 				conf := huh.NewConfirm().
-					Key(fc.Key).
+					Key(fcKey).
 					Value(&b).
 					Title(fc.Title)
 				if fc.Description != "" {
@@ -212,18 +307,13 @@ func (m *Model) buildGroups(groupCfgs []GroupConfig) []*huh.Group {
 				if fc.Negative != "" {
 					conf = conf.Negative(fc.Negative)
 				}
-				if fc.RequireYes {
-					conf = conf.Validate(func(v bool) error {
-						if !v {
-							return fmt.Errorf("Welp, finish up then")
-						}
-						return nil
-					})
+				if validate := boolValidator(fc); validate != nil {
+					conf = conf.Validate(validate)
 				}
 				fields = append(fields, conf)
 			case "multiselect":
-				var vs []string
-				m.Values[fc.Key] = &vs
+				vs, _ := initial[fcKey].([]string)
+				values[fcKey] = &vs
 				ms := huh.NewMultiSelect[string]().
 					Key(fcKey).
 					Value(&vs).
@@ -232,28 +322,84 @@ func (m *Model) buildGroups(groupCfgs []GroupConfig) []*huh.Group {
 				if len(fc.Options) > 0 {
 					ms = ms.Options(huh.NewOptions[string](fc.Options...)...)
 				}
-				if fc.Mandatory {
-					ms = ms.Validate(func(s []string) error {
-						if len(s) == 0 {
-							return fmt.Errorf("%s is required", fc.Title)
-						}
-						return nil
-					})
+				if validate := listValidator(fc, groupKey, values); validate != nil {
+					ms = ms.Validate(validate)
 				}
 				fields = append(fields, ms)
-			default:
-				// unknown field type: ignore
+			case "number":
+				v := initial.GetString(fcKey)
+				values[fcKey] = &v
+				inp := huh.NewInput().
+					Key(fcKey).
+					Value(&v).
+					Title(fc.Title).
+					Description(fc.Description)
+				if validate := stringValidator(fc, groupKey, values); validate != nil {
+					inp = inp.Validate(validate)
+				}
+				fields = append(fields, inp)
+			case "date":
+				v := initial.GetString(fcKey)
+				values[fcKey] = &v
+				inp := huh.NewInput().
+					Key(fcKey).
+					Value(&v).
+					Title(fc.Title).
+					Description(fc.Description).
+					Placeholder("JJJJ-MM-TT")
+				if validate := stringValidator(fc, groupKey, values); validate != nil {
+					inp = inp.Validate(validate)
+				}
+				fields = append(fields, inp)
+			case "ranking":
+				// one select per place, each offering all options; places
+				// start empty so a mandatory ranking must be given
+				vs, _ := initial[fcKey].([]string)
+				slots := make([]*string, len(fc.Options))
+				values[fcKey] = slots
+				options := append([]huh.Option[string]{huh.NewOption("–", "")}, huh.NewOptions[string](fc.Options...)...)
+				fields = append(fields, huh.NewNote().Title(fc.Title).Description(fc.Description))
+				for i := range fc.Options {
+					var v string
+					if i < len(vs) {
+						v = vs[i]
+					}
+					slots[i] = &v
+					fields = append(fields, huh.NewSelect[string]().
+						Key(subFieldKey(fcKey, i)).
+						Value(&v).
+						Title(fmt.Sprintf("%d. Platz", i+1)).
+						Options(options...).
+						Validate(rankValidator(fc, slots, i)))
+				}
+			case "likert":
+				// one select per statement, all on the scale of the field
+				vs, _ := initial[fcKey].([]string)
+				slots := make([]*string, len(fc.Statements))
+				values[fcKey] = slots
+				fields = append(fields, huh.NewNote().Title(fc.Title).Description(fc.Description))
+				for i, statement := range fc.Statements {
+					var v string
+					if i < len(vs) {
+						v = vs[i]
+					}
+					slots[i] = &v
+					sel := huh.NewSelect[string]().
+						Key(subFieldKey(fcKey, i)).
+						Value(&v).
+						Title(statement).
+						Options(rangeOptions(fc)...)
+					if validate := stringValidator(fc, groupKey, values); validate != nil {
+						sel = sel.Validate(validate)
+					}
+					fields = append(fields, sel)
+				}
 			}
 		}
-
-		group := huh.NewGroup(fields...).
-			Title(gcfg.Title).
-			Description(gcfg.Description + "\n")
-
-		res = append(res, group)
+		flush()
 	}
 
-	return res
+	return res, values
 }
 
 func (m Model) Init() tea.Cmd {
@@ -280,24 +426,36 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "ctrl+c":
 			return m, tea.Interrupt
-		case "esc", "q":
-			if m.State == STATE_SUMMARY {
-				m.CreateCertificate()
-			}
-			return m, tea.Quit
 		}
 	}
 
+	if m.ConfirmAbort {
+		return m, tea.Batch(m.UpdateAbortConfirm(msg)...)
+	}
+
 	var cmds []tea.Cmd
 	// persist prev state into the model so the updaters can use it
 	m.PrevState = prev
 
-	// Dispatch to state-specific updaters
-	cmds = append(cmds, m.UpdateMenuModel(msg)...)
-	cmds = append(cmds, m.UpdateDataEntryModel(msg)...)
-	cmds = append(cmds, m.UpdatePrintModel(msg)...)
-	cmds = append(cmds, m.UpdateEvaluationModel(msg)...)
-	cmds = append(cmds, m.UpdateSummaryModel(msg)...)
+	// Dispatch to the updater of the current state only. Updaters switching
+	// to another state return the commands initializing it, so a message is
+	// never handled twice, e.g. by the form it completed and the next one.
+	switch m.State {
+	case STATE_MENU:
+		cmds = append(cmds, m.UpdateMenuModel(msg)...)
+	case STATE_DATA_ENTRY:
+		cmds = append(cmds, m.UpdateDataEntryModel(msg)...)
+	case STATE_PRINT:
+		cmds = append(cmds, m.UpdatePrintModel(msg)...)
+	case STATE_EXPIRY:
+		cmds = append(cmds, m.UpdateExpiryModel(msg)...)
+	case STATE_DASHBOARD:
+		cmds = append(cmds, m.UpdateDashboard(msg)...)
+	case STATE_EVALUATION:
+		cmds = append(cmds, m.UpdateEvaluationModel(msg)...)
+	case STATE_SUMMARY:
+		cmds = append(cmds, m.UpdateSummaryModel(msg)...)
+	}
 
 	return m, tea.Batch(cmds...)
 }
@@ -320,10 +478,18 @@ func (m Model) View() string {
 		header, body, footer = m.ViewDataEntry()
 	}
 
+	if m.State == STATE_DASHBOARD {
+		header, body, footer = m.ViewDashboard()
+	}
+
 	if m.State == STATE_EVALUATION {
 		header, body, footer = m.ViewEvaluation()
 	}
 
+	if m.ConfirmAbort {
+		header, body, footer = m.ViewAbortConfirm()
+	}
+
 	if m.State == STATE_SUMMARY {
 		header, body, footer = m.ViewSummary()
 	}
@@ -332,6 +498,10 @@ func (m Model) View() string {
 		header, body, footer = m.ViewPrint()
 	}
 
+	if m.State == STATE_EXPIRY {
+		header, body, footer = m.ViewExpiry()
+	}
+
 	if len(header) > 0 {
 		currentHeader = "Ceremony Master - " + header
 	}
@@ -374,10 +544,35 @@ func check(e error) {
 
 func main() {
 
-	cfg, cleanUpCallback := initApplication()
+	cfg, cleanUpCallback, cfgErr := initApplication()
 
 	defer cleanUpCallback()
 
+	if len(os.Args) > 1 {
+		// `config validate` reports the errors of the configuration itself
+		err := cfgErr
+		if !needsConfiguration(os.Args[1]) || err == nil {
+			err = runCommand(cfg, os.Args[1:])
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			logger.Printf("command error: %v", err)
+
+			cleanUpCallback()
+			os.Exit(1)
+		}
+		return
+	}
+
+	if cfgErr != nil {
+		// refuse to start the ceremony with a broken configuration
+		if _, err := tea.NewProgram(newConfigErrorModel(cfgErr)).Run(); err != nil {
+			logger.Printf("application error: %v", err)
+		}
+		cleanUpCallback()
+		os.Exit(1)
+	}
+
 	if _, err := tea.NewProgram(NewModel(cfg)).Run(); err != nil {
 		logger.Printf("application error: %v", err)
 
@@ -3,26 +3,75 @@ package main
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 const (
-	STATE_MENU  = "menu"
-	STATE_PRINT = "print"
+	STATE_MENU   = "menu"
+	STATE_PRINT  = "print"
+	STATE_EXPIRY = "expiry"
 )
 
+// menu actions
+const (
+	MENU_START = iota
+	MENU_RESUME
+	MENU_PRINT
+	MENU_EXPIRY
+	MENU_QUIT
+)
+
+type MenuOption struct {
+	Action int
+	Label  string
+}
+
 type MenuState struct {
 	Index   int
-	Options []string
+	Options []MenuOption
+	// Draft is the unfinished ceremony offered for resumption, if any.
+	Draft *Draft
+	// Notice is shown below the options until the next key press, e.g. to
+	// confirm that a certificate was issued.
+	Notice string
 }
 
 func (m *Model) InitMenuModel() {
 
+	draft, err := loadDraft()
+	if err != nil {
+		logger.Printf("Failed to load draft: %v", err)
+	}
+
 	m.Menu = MenuState{
-		Index:   0,
-		Options: []string{"1) Zertifizierung starten...", "2) Zertifikat drucken", "3) Beenden"},
+		Index: 0,
+		Draft: draft,
 	}
+
+	if draft != nil {
+		applicant := answerSheet(draft.DataEntry).GetString(m.Cfg.DataKey(ROLE_APPLICANT))
+		if applicant == "" {
+			applicant = "ohne Namen"
+		}
+		m.Menu.Options = append(m.Menu.Options, MenuOption{MENU_RESUME,
+			fmt.Sprintf("Unterbrochene Zertifizierung fortsetzen (%s, %s)", applicant, draft.Updated.Format("02.01.2006 15:04"))})
+	}
+
+	expiryLabel := "Ablaufende Zertifikate"
+	if all, err := findLatestCertificates(0); err != nil {
+		logger.Printf("Failed to load certificate list: %v", err)
+	} else if n := len(expiringCertificates(all, time.Now(), EXPIRY_WARNING_DAYS)); n > 0 {
+		expiryLabel = fmt.Sprintf("Ablaufende Zertifikate (%d)", n)
+	}
+
+	m.Menu.Options = append(m.Menu.Options,
+		MenuOption{MENU_START, "Zertifizierung starten..."},
+		MenuOption{MENU_PRINT, "Zertifikat drucken"},
+		MenuOption{MENU_EXPIRY, expiryLabel},
+		MenuOption{MENU_QUIT, "Beenden"},
+	)
 }
 
 func (m *Model) UpdateMenuModel(msg tea.Msg) []tea.Cmd {
@@ -35,7 +84,10 @@ func (m *Model) UpdateMenuModel(msg tea.Msg) []tea.Cmd {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		m.Menu.Notice = ""
 		switch msg.String() {
+		case "esc", "q":
+			cmds = append(cmds, tea.Quit)
 		case "up", "k":
 			if m.Menu.Index > 0 {
 				m.Menu.Index--
@@ -45,22 +97,29 @@ func (m *Model) UpdateMenuModel(msg tea.Msg) []tea.Cmd {
 				m.Menu.Index++
 			}
 		case "enter":
-			switch m.Menu.Index {
-			case 0:
-				// Start data entry
+			switch m.Menu.Options[m.Menu.Index].Action {
+			case MENU_START:
+				// Start data entry, a new ceremony replaces an unfinished one
+				removeDraft()
+				m.resetCeremony()
+				m.startedAt = time.Now()
 				m.State = STATE_DATA_ENTRY
-				if m.DataEntry.Form != nil {
-					cmds = append(cmds, m.DataEntry.Form.Init())
-					// clear screen when entering data entry
-					cmds = append(cmds, tea.ClearScreen)
-				}
-			case 1:
+				cmds = append(cmds, m.DataEntry.Form.Init())
+				// clear screen when entering data entry
+				cmds = append(cmds, tea.ClearScreen)
+			case MENU_RESUME:
+				cmds = append(cmds, m.resumeDraft(m.Menu.Draft), tea.ClearScreen)
+			case MENU_PRINT:
 				// Go to print view
 				m.State = STATE_PRINT
 				m.InitPrintModel()
 				// clear screen when entering print view
 				cmds = append(cmds, tea.ClearScreen)
-			case 2:
+			case MENU_EXPIRY:
+				m.State = STATE_EXPIRY
+				m.InitExpiryModel()
+				cmds = append(cmds, tea.ClearScreen)
+			case MENU_QUIT:
 				// Quit application
 				cmds = append(cmds, tea.Quit)
 			}
@@ -79,13 +138,18 @@ func (m Model) ViewMenu() (header string, body string, footer string) {
 	fmt.Fprintf(&b, "\n")
 
 	for i, opt := range m.Menu.Options {
+		label := fmt.Sprintf("%d) %s", i+1, opt.Label)
 		if i == m.Menu.Index {
-			fmt.Fprintf(&b, "> %s\n", s.Highlight.Render(opt))
+			fmt.Fprintf(&b, "> %s\n", s.Highlight.Render(label))
 		} else {
-			fmt.Fprintf(&b, "  %s\n", opt)
+			fmt.Fprintf(&b, "  %s\n", label)
 		}
 	}
 
+	if m.Menu.Notice != "" {
+		fmt.Fprintf(&b, "\n%s\n", s.StatusHeader.Render(m.Menu.Notice))
+	}
+
 	body = b.String()
 	footer = m.appBoundaryView("Navigiere mit Pfeiltasten, Bestätige mit Enter")
 	return header, body, footer
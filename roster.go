@@ -0,0 +1,318 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+const ROSTER_FILE = "roster.yaml"
+
+// RosterEntry is a known reviewer. Certificates reference reviewers by ID
+// so a reviewer keeps their history when their name changes.
+type RosterEntry struct {
+	ID       string `yaml:"id"`
+	Name     string `yaml:"name"`
+	Initials string `yaml:"initials,omitempty"`
+	Role     string `yaml:"role,omitempty"`
+	Active   bool   `yaml:"active"`
+	// Weight is used by the reviewer weighted aggregation, 0 counts as 1.
+	Weight float64 `yaml:"weight,omitempty"`
+}
+
+// Roster holds all known reviewers, it is stored in the data folder.
+type Roster struct {
+	Reviewers []RosterEntry `yaml:"reviewers"`
+}
+
+func getRosterPath() string {
+	return path.Join(getDataPath(), ROSTER_FILE)
+}
+
+// loadRoster reads the roster, a missing roster file is an empty roster.
+func loadRoster() (Roster, error) {
+	var r Roster
+
+	data, err := os.ReadFile(getRosterPath())
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return r, err
+	}
+
+	if err := yaml.Unmarshal(data, &r); err != nil {
+		return r, fmt.Errorf("failed to parse roster %s: %w", getRosterPath(), err)
+	}
+	return r, nil
+}
+
+func (r Roster) save() error {
+	data, err := yaml.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	tmp := getRosterPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, getRosterPath())
+}
+
+// find returns the reviewer with the given name, or with the given initials
+// if no name matches. Both compare case-insensitively.
+func (r *Roster) find(name string) *RosterEntry {
+	name = strings.TrimSpace(name)
+	for i := range r.Reviewers {
+		if strings.EqualFold(r.Reviewers[i].Name, name) {
+			return &r.Reviewers[i]
+		}
+	}
+	for i := range r.Reviewers {
+		if r.Reviewers[i].Initials != "" && strings.EqualFold(r.Reviewers[i].Initials, name) {
+			return &r.Reviewers[i]
+		}
+	}
+	return nil
+}
+
+// resolve returns the roster entry of the reviewer, adding an active entry
+// for names not known yet. It reports whether an entry was added.
+func (r *Roster) resolve(name string) (RosterEntry, bool) {
+	if e := r.find(name); e != nil {
+		return *e, false
+	}
+
+	e := RosterEntry{
+		ID:       uuid.NewString(),
+		Name:     strings.TrimSpace(name),
+		Initials: initialsOf(name),
+		Active:   true,
+	}
+	r.Reviewers = append(r.Reviewers, e)
+	return e, true
+}
+
+// Suggestions returns the names of all active reviewers for autocompletion.
+func (r Roster) Suggestions() []string {
+	var names []string
+	for _, e := range r.Reviewers {
+		if e.Active {
+			names = append(names, e.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// initialsOf builds initials from the first letter of each word of name,
+// e.g. "Anna Maria Müller" -> "AMM".
+func initialsOf(name string) string {
+	var b strings.Builder
+	for _, word := range strings.Fields(name) {
+		for _, c := range word {
+			if unicode.IsLetter(c) {
+				b.WriteRune(unicode.ToUpper(c))
+				break
+			}
+		}
+	}
+	return b.String()
+}
+
+// parseActive reads the active column of a roster CSV, an empty value
+// counts as active.
+func parseActive(s string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "1", "x", "true", "yes", "ja", "aktiv":
+		return true, nil
+	case "0", "false", "no", "nein", "inaktiv":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid active value %q", s)
+}
+
+// importCSV merges reviewers from CSV into the roster. The first row names
+// the columns: `name` is required, `id`, `initials`, `role`, `active` and
+// `weight` are optional. Rows are matched to existing entries by id, then by name;
+// unmatched rows are added. Columns missing from the header leave the values
+// of matched entries unchanged.
+func (r *Roster) importCSV(in io.Reader) (added int, updated int, err error) {
+	rd := csv.NewReader(in)
+	rd.TrimLeadingSpace = true
+
+	header, err := rd.Read()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	cols := make(map[string]int)
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := cols["name"]; !ok {
+		return 0, 0, fmt.Errorf("CSV header lacks a name column")
+	}
+
+	for line := 2; ; line++ {
+		row, err := rd.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return added, updated, err
+		}
+
+		has := func(col string) bool {
+			_, ok := cols[col]
+			return ok
+		}
+		get := func(col string) string {
+			if i, ok := cols[col]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		name := get("name")
+		if name == "" {
+			return added, updated, fmt.Errorf("line %d: missing name", line)
+		}
+		active, err := parseActive(get("active"))
+		if err != nil {
+			return added, updated, fmt.Errorf("line %d: %w", line, err)
+		}
+		var weight float64
+		if w := get("weight"); w != "" {
+			weight, err = strconv.ParseFloat(strings.Replace(w, ",", ".", 1), 64)
+			if err != nil || weight < 0 {
+				return added, updated, fmt.Errorf("line %d: invalid weight %q", line, w)
+			}
+		}
+
+		var e *RosterEntry
+		if id := get("id"); id != "" {
+			for i := range r.Reviewers {
+				if r.Reviewers[i].ID == id {
+					e = &r.Reviewers[i]
+					break
+				}
+			}
+		}
+		if e == nil {
+			for i := range r.Reviewers {
+				if strings.EqualFold(r.Reviewers[i].Name, name) {
+					e = &r.Reviewers[i]
+					break
+				}
+			}
+		}
+
+		if e == nil {
+			id := get("id")
+			if id == "" {
+				id = uuid.NewString()
+			}
+			r.Reviewers = append(r.Reviewers, RosterEntry{ID: id, Initials: initialsOf(name), Active: true})
+			e = &r.Reviewers[len(r.Reviewers)-1]
+			added++
+		} else {
+			updated++
+		}
+
+		// columns missing from the CSV keep the values of the roster
+		e.Name = name
+		if has("initials") {
+			e.Initials = get("initials")
+			if e.Initials == "" {
+				e.Initials = initialsOf(name)
+			}
+		}
+		if has("role") {
+			e.Role = get("role")
+		}
+		if has("active") {
+			e.Active = active
+		}
+		if has("weight") {
+			e.Weight = weight
+		}
+	}
+
+	return added, updated, nil
+}
+
+// runRoster implements the `roster` command.
+func runRoster(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("roster: expected list or import <file.csv>")
+	}
+
+	switch args[0] {
+	case "list":
+		flags := flag.NewFlagSet("roster list", flag.ContinueOnError)
+		all := flags.Bool("all", false, "include inactive reviewers")
+		if _, err := parseArgs(flags, args[1:]); err != nil {
+			return err
+		}
+
+		roster, err := loadRoster()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tKÜRZEL\tROLLE\tGEWICHT\tAKTIV")
+		for _, e := range roster.Reviewers {
+			if !e.Active && !*all {
+				continue
+			}
+			active := "ja"
+			if !e.Active {
+				active = "nein"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%g\t%s\n", e.ID, e.Name, e.Initials, e.Role, effectiveWeight(e.Weight), active)
+		}
+		return w.Flush()
+	case "import":
+		if len(args) != 2 {
+			return fmt.Errorf("roster import: expected exactly one CSV file")
+		}
+
+		f, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		roster, err := loadRoster()
+		if err != nil {
+			return err
+		}
+
+		added, updated, err := roster.importCSV(f)
+		if err != nil {
+			return fmt.Errorf("roster import %s: %w", args[1], err)
+		}
+		if err := roster.save(); err != nil {
+			return err
+		}
+
+		fmt.Printf("%d Zertifizierer hinzugefügt, %d aktualisiert.\n", added, updated)
+		return nil
+	default:
+		return fmt.Errorf("roster: unknown subcommand %q", args[0])
+	}
+}
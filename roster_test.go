@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImportCSVKeepsMissingColumns(t *testing.T) {
+	var r Roster
+	full := "name,initials,role,active,weight\nErika Mustermann,EM2,Jury,nein,\"2,5\"\n"
+	if _, _, err := r.importCSV(strings.NewReader(full)); err != nil {
+		t.Fatal(err)
+	}
+
+	added, updated, err := r.importCSV(strings.NewReader("name\nerika mustermann\nMax Mustermann\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 1 || updated != 1 {
+		t.Fatalf("added %d, updated %d", added, updated)
+	}
+
+	e := r.find("Erika Mustermann")
+	if e == nil || e.Initials != "EM2" || e.Role != "Jury" || e.Active || e.Weight != 2.5 {
+		t.Errorf("names-only import changed the entry: %+v", e)
+	}
+	if e := r.find("Max Mustermann"); e == nil || e.Initials != "MM" || !e.Active {
+		t.Errorf("added entry lacks defaults: %+v", e)
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationRule restricts the values of a field beyond mandatory, e.g.
+//
+//	rules:
+//	- pattern: '^[A-ZÄÖÜ]'
+//	  message: Bitte mit einem Großbuchstaben beginnen
+//	- required: true
+//	  when:
+//	  - field: rating
+//	    max: 2
+//	  - field: rating
+//	    equals: "5"
+//	  message: Bitte begründe die Bewertung
+//
+// The checks of a rule apply to filled in values only, except required. A
+// rule with conditions applies only while one of them holds.
+type ValidationRule struct {
+	Required bool   `yaml:"required,omitempty"`
+	Pattern  string `yaml:"pattern,omitempty"` // regular expression the value must match
+	// MinLength and MaxLength count characters, or the chosen options of
+	// multiselects.
+	MinLength int      `yaml:"min_length,omitempty"`
+	MaxLength int      `yaml:"max_length,omitempty"`
+	Min       *float64 `yaml:"min,omitempty"` // the value as a number
+	Max       *float64 `yaml:"max,omitempty"`
+	// MaxFileSize limits the file of a filepicker, e.g. 500KB or 5MB.
+	MaxFileSize string `yaml:"max_file_size,omitempty"`
+	// The dimensions of the picture of a filepicker in pixels.
+	MinWidth  int `yaml:"min_width,omitempty"`
+	MaxWidth  int `yaml:"max_width,omitempty"`
+	MinHeight int `yaml:"min_height,omitempty"`
+	MaxHeight int `yaml:"max_height,omitempty"`
+	// When lists the conditions of the rule, any of them has to hold.
+	When Conditions `yaml:"when,omitempty"`
+	// Message replaces the error shown when the rule is violated.
+	Message string `yaml:"message,omitempty"`
+}
+
+// applies reports whether the rule applies given the other values of the
+// form, which may be nil if they are unknown.
+func (r ValidationRule) applies(groupKey string, form fieldReader) bool {
+	if len(r.When) == 0 {
+		return true
+	}
+	return form != nil && r.When.hold(groupKey, form)
+}
+
+// fail returns the configured message of the rule or the given default.
+func (r ValidationRule) fail(format string, args ...any) error {
+	if r.Message != "" {
+		return fmt.Errorf("%s", r.Message)
+	}
+	return fmt.Errorf(format, args...)
+}
+
+// checkString checks a string value of the field against the rule.
+func (r ValidationRule) checkString(fc FieldConfig, s string) error {
+	if strings.TrimSpace(s) == "" {
+		if r.Required {
+			return r.fail("%s is required", fc.Title)
+		}
+		return nil
+	}
+	if r.Pattern != "" {
+		if re, err := regexp.Compile(r.Pattern); err == nil && !re.MatchString(s) {
+			return r.fail("%s: %q does not match %s", fc.Title, s, r.Pattern)
+		}
+	}
+	if n := utf8.RuneCountInString(s); r.MinLength > 0 && n < r.MinLength {
+		return r.fail("%s must be at least %d characters long", fc.Title, r.MinLength)
+	} else if r.MaxLength > 0 && n > r.MaxLength {
+		return r.fail("%s must be at most %d characters long", fc.Title, r.MaxLength)
+	}
+	if r.Min != nil || r.Max != nil {
+		n, err := parseNumber(s)
+		switch {
+		case err != nil:
+			return r.fail("%s: %q is no number", fc.Title, s)
+		case r.Min != nil && n < *r.Min:
+			return r.fail("%s must be at least %s", fc.Title, formatRating(*r.Min))
+		case r.Max != nil && n > *r.Max:
+			return r.fail("%s must be at most %s", fc.Title, formatRating(*r.Max))
+		}
+	}
+	if fc.Type == "filepicker" {
+		return r.checkFile(fc, s)
+	}
+	return nil
+}
+
+// checkFile checks the size and picture dimensions of the file at path.
+func (r ValidationRule) checkFile(fc FieldConfig, path string) error {
+	if r.MaxFileSize != "" {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fc.Title, err)
+		}
+		if limit, err := parseFileSize(r.MaxFileSize); err == nil && info.Size() > limit {
+			return r.fail("%s: the file must not be larger than %s", fc.Title, r.MaxFileSize)
+		}
+	}
+	if r.MinWidth == 0 && r.MaxWidth == 0 && r.MinHeight == 0 && r.MaxHeight == 0 {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fc.Title, err)
+	}
+	defer f.Close()
+	img, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return r.fail("%s: %s is no picture", fc.Title, path)
+	}
+	if (r.MinWidth > 0 && img.Width < r.MinWidth) || (r.MaxWidth > 0 && img.Width > r.MaxWidth) ||
+		(r.MinHeight > 0 && img.Height < r.MinHeight) || (r.MaxHeight > 0 && img.Height > r.MaxHeight) {
+		return r.fail("%s: the picture has %dx%d pixels, allowed are %s", fc.Title, img.Width, img.Height, r.dimensions())
+	}
+	return nil
+}
+
+// dimensions describes the allowed picture dimensions, e.g. "width 800 to
+// 4000, height at least 600".
+func (r ValidationRule) dimensions() string {
+	between := func(name string, lo, hi int) string {
+		switch {
+		case lo > 0 && hi > 0:
+			return fmt.Sprintf("%s %d to %d", name, lo, hi)
+		case lo > 0:
+			return fmt.Sprintf("%s at least %d", name, lo)
+		case hi > 0:
+			return fmt.Sprintf("%s at most %d", name, hi)
+		}
+		return ""
+	}
+	var parts []string
+	for _, p := range []string{between("width", r.MinWidth, r.MaxWidth), between("height", r.MinHeight, r.MaxHeight)} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// checkList checks the options chosen in a multiselect against the rule.
+func (r ValidationRule) checkList(fc FieldConfig, vs []string) error {
+	switch {
+	case len(vs) == 0 && r.Required:
+		return r.fail("%s is required", fc.Title)
+	case len(vs) > 0 && r.MinLength > 0 && len(vs) < r.MinLength:
+		return r.fail("%s: choose at least %d", fc.Title, r.MinLength)
+	case r.MaxLength > 0 && len(vs) > r.MaxLength:
+		return r.fail("%s: choose at most %d", fc.Title, r.MaxLength)
+	}
+	return nil
+}
+
+// checkRules checks value against the rules of the field which apply given
+// the other values of the form.
+func checkRules(fc FieldConfig, groupKey string, form fieldReader, value any) error {
+	for _, r := range fc.Rules {
+		if !r.applies(groupKey, form) {
+			continue
+		}
+		var err error
+		switch v := value.(type) {
+		case string:
+			err = r.checkString(fc, v)
+		case []string:
+			err = r.checkList(fc, v)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseFileSize parses a file size like 500KB, 5MB or 1024 (bytes).
+func parseFileSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	unit := int64(1)
+	for _, u := range []struct {
+		suffix string
+		factor int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, u.suffix) {
+			s, unit = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.factor
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid file size %q, expected e.g. 500KB or 5MB", s)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+// validateRules checks the rules of the field, keys holds the field keys
+// of the form the field belongs to.
+func (fc FieldConfig) validateRules(groupKey string, keys []string) error {
+	for i, r := range fc.Rules {
+		where := fmt.Sprintf("rule %d", i+1)
+		if !r.Required && r.Pattern == "" && r.MinLength == 0 && r.MaxLength == 0 && r.Min == nil && r.Max == nil &&
+			r.MaxFileSize == "" && r.MinWidth == 0 && r.MaxWidth == 0 && r.MinHeight == 0 && r.MaxHeight == 0 {
+			return fmt.Errorf("%s: nothing to check", where)
+		}
+		if r.Pattern != "" {
+			if _, err := regexp.Compile(r.Pattern); err != nil {
+				return fmt.Errorf("%s: invalid pattern: %w", where, err)
+			}
+		}
+		if r.MinLength < 0 || r.MaxLength < 0 || (r.MaxLength > 0 && r.MinLength > r.MaxLength) {
+			return fmt.Errorf("%s: invalid length %d to %d", where, r.MinLength, r.MaxLength)
+		}
+		if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+			return fmt.Errorf("%s: min %s exceeds max %s", where, formatRating(*r.Min), formatRating(*r.Max))
+		}
+		if r.MaxFileSize != "" || r.MinWidth != 0 || r.MaxWidth != 0 || r.MinHeight != 0 || r.MaxHeight != 0 {
+			if fc.Type != "filepicker" {
+				return fmt.Errorf("%s: file size and dimensions are only supported by filepicker fields", where)
+			}
+		}
+		if r.MaxFileSize != "" {
+			if _, err := parseFileSize(r.MaxFileSize); err != nil {
+				return fmt.Errorf("%s: %w", where, err)
+			}
+		}
+		if r.MinWidth < 0 || r.MaxWidth < 0 || r.MinHeight < 0 || r.MaxHeight < 0 {
+			return fmt.Errorf("%s: dimensions must not be negative", where)
+		}
+		switch fc.Type {
+		case "confirm", "ranking", "likert":
+			return fmt.Errorf("%s: rules are not supported by %s fields", where, fc.Type)
+		}
+		if err := r.When.validate(groupKey, keys); err != nil {
+			return fmt.Errorf("%s: %w", where, err)
+		}
+	}
+	return nil
+}
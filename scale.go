@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Display styles of rating scales.
+const (
+	SCALE_STARS   = "stars"   // ⭐ per point, ½ for half points
+	SCALE_NUMBERS = "numbers" // the value itself
+	SCALE_WORDS   = "words"   // the label of the value, the value if it has none
+)
+
+// RatingScale defines the values offered by a range field, e.g.
+//
+//	scale:
+//	  min: 1
+//	  max: 10
+//	  step: 0.5
+//	  display: numbers
+//	  labels:
+//	    3: solide, etwas trocken
+//
+// Labels are keyed by value and shown next to the value, or instead of it
+// for the words display.
+type RatingScale struct {
+	Min     float64           `yaml:"min" json:"min"`
+	Max     float64           `yaml:"max" json:"max"`
+	Step    float64           `yaml:"step,omitempty" json:"step,omitempty"`
+	Display string            `yaml:"display,omitempty" json:"display,omitempty"`
+	Labels  map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+}
+
+// defaultScale is the scale of range fields without one: 0 to 5 stars.
+var defaultScale = RatingScale{Min: 0, Max: 5, Step: 1, Display: SCALE_STARS}
+
+// likertScale is the scale of likert fields without one.
+var likertScale = RatingScale{Min: 1, Max: 5, Step: 1, Display: SCALE_WORDS, Labels: map[string]string{
+	"1": "stimme gar nicht zu",
+	"2": "stimme eher nicht zu",
+	"3": "teils, teils",
+	"4": "stimme eher zu",
+	"5": "stimme voll zu",
+}}
+
+// RatingScale returns the scale of a range or likert field with defaults
+// applied.
+func (fc FieldConfig) RatingScale() RatingScale {
+	if fc.Scale == nil {
+		if fc.Type == "likert" {
+			return likertScale
+		}
+		return defaultScale
+	}
+	return fc.Scale.withDefaults()
+}
+
+func (s RatingScale) withDefaults() RatingScale {
+	if s.Step == 0 {
+		s.Step = 1
+	}
+	if s.Display == "" {
+		s.Display = SCALE_STARS
+	}
+	return s
+}
+
+// scaleOrDefault returns the scale stored on a certificate question, the
+// default scale for certificates of older versions.
+func scaleOrDefault(s *RatingScale) RatingScale {
+	if s == nil {
+		return defaultScale
+	}
+	return s.withDefaults()
+}
+
+func (s RatingScale) validate() error {
+	s = s.withDefaults()
+	if s.Max <= s.Min {
+		return fmt.Errorf("scale: max %s must be greater than min %s", formatRating(s.Max), formatRating(s.Min))
+	}
+	if s.Step < 0 {
+		return fmt.Errorf("scale: step must not be negative")
+	}
+	if steps := (s.Max - s.Min) / s.Step; math.Abs(steps-math.Round(steps)) > 1e-9 {
+		return fmt.Errorf("scale: %s to %s cannot be divided into steps of %s", formatRating(s.Min), formatRating(s.Max), formatRating(s.Step))
+	}
+	switch s.Display {
+	case SCALE_STARS, SCALE_NUMBERS, SCALE_WORDS:
+	default:
+		return fmt.Errorf("scale: unknown display %q, expected %s, %s or %s", s.Display, SCALE_STARS, SCALE_NUMBERS, SCALE_WORDS)
+	}
+	for k := range s.Labels {
+		v, err := strconv.ParseFloat(k, 64)
+		if err != nil || !s.contains(v) {
+			return fmt.Errorf("scale: label %q is no value of the scale", k)
+		}
+	}
+	return nil
+}
+
+// Values returns every value of the scale in ascending order.
+func (s RatingScale) Values() []float64 {
+	s = s.withDefaults()
+	var res []float64
+	n := int(math.Round((s.Max - s.Min) / s.Step))
+	for i := 0; i <= n; i++ {
+		res = append(res, roundRating(s.Min+float64(i)*s.Step))
+	}
+	return res
+}
+
+func (s RatingScale) contains(v float64) bool {
+	for _, x := range s.Values() {
+		if x == roundRating(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Label returns the rubric label of the value, empty if it has none.
+func (s RatingScale) Label(v float64) string {
+	keys := make([]string, 0, len(s.Labels))
+	for k := range s.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if x, err := strconv.ParseFloat(k, 64); err == nil && roundRating(x) == roundRating(v) {
+			return s.Labels[k]
+		}
+	}
+	return ""
+}
+
+// Format renders a value in the display style of the scale, followed by its
+// label.
+func (s RatingScale) Format(v float64) string {
+	s = s.withDefaults()
+	label := s.Label(v)
+
+	var res string
+	switch s.Display {
+	case SCALE_WORDS:
+		if label != "" {
+			return label
+		}
+		return formatRating(v)
+	case SCALE_NUMBERS:
+		res = formatRating(v)
+	default:
+		res = stars(v)
+	}
+	if label != "" {
+		if res == "" {
+			return label
+		}
+		return res + " " + label
+	}
+	return res
+}
+
+// Normalize maps v linearly onto the scale to, so results on different
+// scales can be combined.
+func (s RatingScale) Normalize(v float64, to RatingScale) float64 {
+	if s.Min == to.Min && s.Max == to.Max {
+		return v
+	}
+	return to.Min + (v-s.Min)/(s.Max-s.Min)*(to.Max-to.Min)
+}
+
+// String describes the range of the scale, e.g. "0–5".
+func (s RatingScale) String() string {
+	return formatRating(s.Min) + "–" + formatRating(s.Max)
+}
+
+// stars renders a rating as stars, with ½ for a remaining half point.
+func stars(v float64) string {
+	if v <= 0 {
+		return ""
+	}
+	full := int(math.Floor(v))
+	res := strings.Repeat("⭐", full)
+	if v-float64(full) >= 0.5 {
+		res += "½"
+	}
+	return res
+}
+
+// formatRating renders a rating without superfluous decimals, e.g. 3 or 2.5.
+func formatRating(v float64) string {
+	return strconv.FormatFloat(roundRating(v), 'f', -1, 64)
+}
+
+// parseRating parses a rating entered in a form.
+func parseRating(s string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSpace(s), 64)
+}
+
+// roundRating removes floating point noise from computed scale values.
+func roundRating(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Aggregation strategies combine the ratings of a group, or the group
+// results into the total.
+const (
+	AGGREGATION_MEAN              = "mean"              // (weighted) arithmetic mean
+	AGGREGATION_MEDIAN            = "median"            // (weighted) median
+	AGGREGATION_TRIMMED           = "trimmed"           // mean without the highest and the lowest value
+	AGGREGATION_GEOMETRIC         = "geometric"         // (weighted) geometric mean
+	AGGREGATION_REVIEWER_WEIGHTED = "reviewer_weighted" // mean weighted by the reviewers' roster weights
+)
+
+// aggregationLabels names the strategies on screen and on certificates.
+var aggregationLabels = map[string]string{
+	AGGREGATION_MEAN:              "Mittelwert",
+	AGGREGATION_MEDIAN:            "Median",
+	AGGREGATION_TRIMMED:           "Mittelwert ohne höchste und niedrigste Wertung",
+	AGGREGATION_GEOMETRIC:         "Geometrisches Mittel",
+	AGGREGATION_REVIEWER_WEIGHTED: "Nach Zertifizierern gewichteter Mittelwert",
+}
+
+// aggregationOrDefault returns the strategy to use for a configured
+// strategy, which may be empty.
+func aggregationOrDefault(strategy string) string {
+	if strategy == "" {
+		return AGGREGATION_MEAN
+	}
+	return strategy
+}
+
+func validateAggregation(strategy string) error {
+	if _, ok := aggregationLabels[aggregationOrDefault(strategy)]; !ok {
+		return fmt.Errorf("unknown aggregation %q", strategy)
+	}
+	return nil
+}
+
+// aggregate combines values according to strategy, weights holds the
+// weight of each value. The reviewer weighted mean is a weighted mean as
+// well, it is up to the caller to pass the reviewer weights.
+func aggregate(strategy string, values []float64, weights []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	switch aggregationOrDefault(strategy) {
+	case AGGREGATION_MEDIAN:
+		return weightedMedian(values, weights)
+	case AGGREGATION_TRIMMED:
+		if len(values) < 3 {
+			return weightedMean(values, weights)
+		}
+		vs, ws := sortedByValue(values, weights)
+		return weightedMean(vs[1:len(vs)-1], ws[1:len(ws)-1])
+	case AGGREGATION_GEOMETRIC:
+		var sum, weightSum float64
+		for i, v := range values {
+			if v <= 0 {
+				return 0
+			}
+			sum += weights[i] * math.Log(v)
+			weightSum += weights[i]
+		}
+		if weightSum == 0 {
+			return 0
+		}
+		return math.Exp(sum / weightSum)
+	default:
+		return weightedMean(values, weights)
+	}
+}
+
+func weightedMean(values []float64, weights []float64) float64 {
+	var sum, weightSum float64
+	for i, v := range values {
+		sum += weights[i] * v
+		weightSum += weights[i]
+	}
+	if weightSum == 0 {
+		return 0
+	}
+	return sum / weightSum
+}
+
+// weightedMedian returns the value at half of the total weight. If the
+// half falls exactly between two values their mean is returned, so equal
+// weights give the common median.
+func weightedMedian(values []float64, weights []float64) float64 {
+	vs, ws := sortedByValue(values, weights)
+
+	var total float64
+	for _, w := range ws {
+		total += w
+	}
+
+	var cum float64
+	for i, v := range vs {
+		cum += ws[i]
+		if cum > total/2 {
+			return v
+		}
+		if cum == total/2 && i+1 < len(vs) {
+			return (v + vs[i+1]) / 2
+		}
+	}
+	return vs[len(vs)-1]
+}
+
+func sortedByValue(values []float64, weights []float64) ([]float64, []float64) {
+	idxs := make([]int, len(values))
+	for i := range idxs {
+		idxs[i] = i
+	}
+	sort.SliceStable(idxs, func(a, b int) bool { return values[idxs[a]] < values[idxs[b]] })
+
+	vs := make([]float64, len(values))
+	ws := make([]float64, len(values))
+	for i, idx := range idxs {
+		vs[i] = values[idx]
+		ws[i] = weights[idx]
+	}
+	return vs, ws
+}
+
+// ScoreBreakdown explains how the result of a ceremony was computed. The
+// summary screen, the certificate and its template all use the same
+// breakdown, computed by scoreQuestions.
+type ScoreBreakdown struct {
+	Groups    []GroupScore    `yaml:"groups" json:"groups"`
+	Reviewers []ReviewerScore `yaml:"reviewers" json:"reviewers"`
+	// Aggregation is the strategy combining the group results into Total,
+	// unless Total is computed by Formula.
+	Aggregation string `yaml:"aggregation" json:"aggregation"`
+	Formula     string `yaml:"formula,omitempty" json:"formula,omitempty"`
+	// WeightSum is the sum of the weights of all rated groups.
+	WeightSum float64 `yaml:"weight_sum" json:"weight_sum"`
+	// Total is the overall score on Scale.
+	Total float64     `yaml:"total" json:"total"`
+	Scale RatingScale `yaml:"scale" json:"scale"`
+	// Levels lists every skill level with whether Total reached it, Level
+	// is the highest level reached.
+	Levels []LevelCheck `yaml:"levels,omitempty" json:"levels,omitempty"`
+	Level  string       `yaml:"level,omitempty" json:"level,omitempty"`
+	// Performance is the highest level whose rules the ratings met, without
+	// the rules about earlier certificates. Progressions count it.
+	Performance string `yaml:"performance,omitempty" json:"performance,omitempty"`
+}
+
+// GroupScore holds the ratings of one evaluation group.
+type GroupScore struct {
+	Key         string          `yaml:"key,omitempty" json:"key,omitempty"`
+	Title       string          `yaml:"title" json:"title"`
+	Aggregation string          `yaml:"aggregation" json:"aggregation"`
+	Weight      float64         `yaml:"weight" json:"weight"`
+	Scale       RatingScale     `yaml:"scale" json:"scale"`
+	Values      []ReviewerValue `yaml:"values" json:"values"`
+	// Avg is the result of the group according to Aggregation on the scale
+	// of the group.
+	Avg float64 `yaml:"avg" json:"avg"`
+	Min float64 `yaml:"min" json:"min"`
+	Max float64 `yaml:"max" json:"max"`
+	// Scaled is Avg on the scale of the total, Weighted is Scaled
+	// multiplied by Weight.
+	Scaled   float64 `yaml:"scaled" json:"scaled"`
+	Weighted float64 `yaml:"weighted" json:"weighted"`
+}
+
+// ReviewerValue is the rating of a single reviewer.
+type ReviewerValue struct {
+	Name   string  `yaml:"name" json:"name"`
+	Value  float64 `yaml:"value" json:"value"`
+	Weight float64 `yaml:"weight" json:"weight"`
+}
+
+// ReviewerScore is the weighted average of all ratings of a reviewer.
+type ReviewerScore struct {
+	Name   string  `yaml:"name" json:"name"`
+	Weight float64 `yaml:"weight" json:"weight"`
+	Avg    float64 `yaml:"avg" json:"avg"`
+}
+
+// LevelCheck tells whether the rules of a skill level were met, Blockers
+// describes the rules which were not.
+type LevelCheck struct {
+	Level     int      `yaml:"level" json:"level"`
+	Name      string   `yaml:"name" json:"name"`
+	MinPoints float64  `yaml:"min_points" json:"min_points"`
+	Met       bool     `yaml:"met" json:"met"`
+	Blockers  []string `yaml:"blockers,omitempty" json:"blockers,omitempty"`
+	// Remaining is the number of ceremonies still missing for the
+	// progression of the level.
+	Remaining int `yaml:"remaining,omitempty" json:"remaining,omitempty"`
+}
+
+// effectiveWeight returns the weight a group or reviewer counts with, an
+// omitted weight counts as 1.
+func effectiveWeight(w float64) float64 {
+	if w == 0 {
+		return 1
+	}
+	return w
+}
+
+// scoreQuestions computes the score of the given certificate questions.
+// The ratings of each rated group are combined by the group's aggregation
+// strategy, the group results by the configured formula or else by the
+// overall strategy taking the group weights into account, so the total
+// stays on the scale of the ratings. Groups rated on another scale than the
+// total, see totalScale, are mapped onto that scale first. Unscored groups and groups without
+// responses are left out. standing is the applicant's career from earlier
+// certificates, see checkLevels.
+func scoreQuestions(questions []CertificateQuestion, cfg Configuration, standing *Standing) ScoreBreakdown {
+	b := ScoreBreakdown{
+		Aggregation: aggregationOrDefault(cfg.Aggregation),
+		Scale:       totalScale(questions, cfg.Scale),
+	}
+
+	reviewerSums := make(map[string]float64)
+	reviewerWeights := make(map[string]float64)
+
+	var groupResults, groupWeights []float64
+	for _, q := range questions {
+		if q.Unscored || len(q.Responses) == 0 {
+			continue
+		}
+
+		g := GroupScore{
+			Key:         q.Key,
+			Title:       q.Question,
+			Aggregation: aggregationOrDefault(q.Aggregation),
+			Weight:      effectiveWeight(q.Weight),
+			Scale:       scaleOrDefault(q.Scale),
+			Min:         math.Inf(1),
+			Max:         math.Inf(-1),
+		}
+
+		var values, weights []float64
+		for _, r := range q.Responses {
+			v := r.Value
+			rw := effectiveWeight(r.ReviewerWeight)
+			g.Values = append(g.Values, ReviewerValue{Name: r.Name, Value: r.Value, Weight: rw})
+			values = append(values, v)
+			if g.Aggregation == AGGREGATION_REVIEWER_WEIGHTED {
+				weights = append(weights, rw)
+			} else {
+				weights = append(weights, 1)
+			}
+			g.Min = math.Min(g.Min, v)
+			g.Max = math.Max(g.Max, v)
+
+			if _, ok := reviewerWeights[r.Name]; !ok {
+				b.Reviewers = append(b.Reviewers, ReviewerScore{Name: r.Name, Weight: rw})
+			}
+			reviewerSums[r.Name] += g.Scale.Normalize(v, b.Scale) * g.Weight
+			reviewerWeights[r.Name] += g.Weight
+		}
+		g.Avg = aggregate(g.Aggregation, values, weights)
+		g.Scaled = g.Scale.Normalize(g.Avg, b.Scale)
+		g.Weighted = g.Scaled * g.Weight
+
+		groupResults = append(groupResults, g.Scaled)
+		groupWeights = append(groupWeights, g.Weight)
+		b.WeightSum += g.Weight
+		b.Groups = append(b.Groups, g)
+	}
+
+	var reviewerResults, rWeights []float64
+	for i, r := range b.Reviewers {
+		if reviewerWeights[r.Name] > 0 {
+			b.Reviewers[i].Avg = reviewerSums[r.Name] / reviewerWeights[r.Name]
+		}
+		reviewerResults = append(reviewerResults, b.Reviewers[i].Avg)
+		rWeights = append(rWeights, r.Weight)
+	}
+
+	switch {
+	case cfg.total != nil:
+		// like the aggregations the formula combines the group results on
+		// the scale of the total, which the level thresholds refer to
+		vars := make(map[string]float64, len(b.Groups))
+		for _, g := range b.Groups {
+			vars[g.Key] = g.Scaled
+		}
+		b.Formula = cfg.Formula
+		b.Total = cfg.total.eval(vars)
+	case b.Aggregation == AGGREGATION_REVIEWER_WEIGHTED:
+		b.Total = aggregate(b.Aggregation, reviewerResults, rWeights)
+	default:
+		b.Total = aggregate(b.Aggregation, groupResults, groupWeights)
+	}
+
+	b.checkLevels(cfg.SkillLevels, standing, time.Now())
+
+	return b
+}
+
+// totalScale returns the scale of the total score: the configured one, else
+// the scale shared by all rated questions, else the default scale.
+func totalScale(questions []CertificateQuestion, configured *RatingScale) RatingScale {
+	if configured != nil {
+		return configured.withDefaults()
+	}
+
+	var res *RatingScale
+	for _, q := range questions {
+		if q.Unscored || len(q.Responses) == 0 {
+			continue
+		}
+		s := scaleOrDefault(q.Scale)
+		if res == nil {
+			res = &s
+		} else if res.Min != s.Min || res.Max != s.Max {
+			return defaultScale
+		}
+	}
+	if res == nil {
+		return defaultScale
+	}
+	return *res
+}
+
+// AggregationLabel names the overall aggregation strategy.
+func (b ScoreBreakdown) AggregationLabel() string {
+	if b.Formula != "" {
+		return "Formel"
+	}
+	return aggregationLabels[aggregationOrDefault(b.Aggregation)]
+}
+
+// scoredGroupKeys returns the keys of the evaluation groups with a score
+// field.
+func scoredGroupKeys(groups []GroupConfig) []string {
+	var keys []string
+	for _, g := range groups {
+		if g.FieldKey(ROLE_SCORE) != "" {
+			keys = append(keys, g.Key)
+		}
+	}
+	return keys
+}
+
+// AggregationLabel names the aggregation strategy of the group.
+func (g GroupScore) AggregationLabel() string {
+	return aggregationLabels[aggregationOrDefault(g.Aggregation)]
+}
+
+// WeightedSum returns the sum of the weighted group averages.
+func (b ScoreBreakdown) WeightedSum() float64 {
+	var sum float64
+	for _, g := range b.Groups {
+		sum += g.Weighted
+	}
+	return sum
+}
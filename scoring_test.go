@@ -0,0 +1,48 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFormulaTotal(t *testing.T) {
+	f, err := parseFormula("0.5*taste + 0.5*max(smell, 4)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := Configuration{Formula: "0.5*taste + 0.5*max(smell, 4)", total: f}
+	questions := []CertificateQuestion{
+		{Key: "taste", Responses: []CertificateResponse{{Name: "Anna", Value: 5}, {Name: "Bernd", Value: 3}}},
+		{Key: "smell", Responses: []CertificateResponse{{Name: "Anna", Value: 3}}},
+	}
+
+	b := scoreQuestions(questions, cfg, nil)
+	if want := 4.0; math.Abs(b.Total-want) > 1e-9 {
+		t.Errorf("total = %v, want %v", b.Total, want)
+	}
+	if b.Formula != cfg.Formula {
+		t.Errorf("formula = %q, want %q", b.Formula, cfg.Formula)
+	}
+}
+
+// The formula reads the group results on the scale of the total like the
+// aggregations do.
+func TestFormulaUsesScaledGroupResults(t *testing.T) {
+	f, err := parseFormula("0.5*taste + 0.5*smell")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := Configuration{Formula: "0.5*taste + 0.5*smell", Scale: &RatingScale{Min: 0, Max: 5}, total: f}
+	questions := []CertificateQuestion{
+		{Key: "taste", Scale: &RatingScale{Min: 0, Max: 10}, Responses: []CertificateResponse{{Name: "Anna", Value: 10}}},
+		{Key: "smell", Responses: []CertificateResponse{{Name: "Anna", Value: 3}}},
+	}
+
+	b := scoreQuestions(questions, cfg, nil)
+	if want := 4.0; math.Abs(b.Total-want) > 1e-9 {
+		t.Errorf("total = %v, want %v", b.Total, want)
+	}
+	if b.Formula != cfg.Formula {
+		t.Errorf("formula = %q, want %q", b.Formula, cfg.Formula)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const STANDINGS_FILE = "standings.yaml"
+
+// Standing is the career of an applicant on a track: the highest level
+// granted so far and every certificate which counts towards the next
+// levels.
+type Standing struct {
+	Applicant string `yaml:"applicant"`
+	// Track is the key of the track, see TrackConfig.
+	Track   string          `yaml:"track,omitempty"`
+	Level   string          `yaml:"level,omitempty"`
+	Since   time.Time       `yaml:"since,omitempty"`
+	History []StandingEntry `yaml:"history"`
+}
+
+// StandingEntry records a certificate of the applicant. Performance is the
+// highest level whose rules the ceremony met, Level the level granted.
+type StandingEntry struct {
+	Certificate string    `yaml:"certificate"`
+	Date        time.Time `yaml:"date"`
+	Performance string    `yaml:"performance,omitempty"`
+	Level       string    `yaml:"level,omitempty"`
+}
+
+// Standings holds the standing of every applicant, it is stored in the data
+// folder.
+type Standings struct {
+	Applicants []Standing `yaml:"applicants"`
+}
+
+func getStandingsPath() string {
+	return path.Join(getDataPath(), STANDINGS_FILE)
+}
+
+// loadStandings reads the standings, a missing file means no standings.
+func loadStandings() (Standings, error) {
+	var s Standings
+
+	data, err := os.ReadFile(getStandingsPath())
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("failed to parse standings %s: %w", getStandingsPath(), err)
+	}
+	return s, nil
+}
+
+func (s Standings) save() error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	tmp := getStandingsPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, getStandingsPath())
+}
+
+// find returns the standing of the applicant compared case-insensitively on
+// the track of the ceremony configuration, nil if there is none.
+func (s *Standings) find(cfg Configuration, applicant string) *Standing {
+	applicant = strings.TrimSpace(applicant)
+	for i := range s.Applicants {
+		if strings.EqualFold(s.Applicants[i].Applicant, applicant) && cfg.onTrack(s.Applicants[i].Track) {
+			return &s.Applicants[i]
+		}
+	}
+	return nil
+}
+
+// loadStanding returns the standing of the applicant on the track of the
+// ceremony configuration. Applicants without a stored standing get one
+// derived from their certificates on the track, so certificates issued
+// before standings existed count as well.
+func loadStanding(cfg Configuration, applicant string) Standing {
+	standings, err := loadStandings()
+	if err != nil {
+		logger.Printf("Failed to load standings: %v", err)
+	}
+	if s := standings.find(cfg, applicant); s != nil {
+		return *s
+	}
+
+	levels := cfg.SkillLevels
+	standing := Standing{Applicant: strings.TrimSpace(applicant), Track: cfg.track}
+
+	idx, err := loadCertificateIndex()
+	if err != nil {
+		logger.Printf("Failed to load certificate index: %v", err)
+		return standing
+	}
+
+	var certs []CertificateSummary
+	for _, c := range idx.summaries() {
+		if strings.EqualFold(strings.TrimSpace(c.Applicant), standing.Applicant) && cfg.onTrack(c.Track) {
+			certs = append(certs, c)
+		}
+	}
+	sort.Slice(certs, func(i, j int) bool { return certs[i].Date.Before(certs[j].Date) })
+
+	for _, c := range certs {
+		level := c.Rank
+		if level == "" {
+			level = rankForScore(levels, c.Score)
+		}
+		standing.add(levels, StandingEntry{Certificate: c.ID(), Date: c.Date, Performance: level, Level: level})
+	}
+	return standing
+}
+
+// add records a certificate and raises the standing to its level if that
+// is higher in the order of levels.
+func (s *Standing) add(levels []SkillLevelConfig, e StandingEntry) {
+	s.History = append(s.History, e)
+	if levelIndex(levels, e.Level) > levelIndex(levels, s.Level) {
+		s.Level = e.Level
+		s.Since = e.Date
+	}
+}
+
+// levelIndex returns the position of the named level in levels, -1 if it
+// is unknown or empty.
+func levelIndex(levels []SkillLevelConfig, name string) int {
+	for i, l := range levels {
+		if name != "" && l.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// held returns the level number of the standing, -1 if the applicant holds
+// no level.
+func (s Standing) held(levels []SkillLevelConfig) int {
+	if i := levelIndex(levels, s.Level); i >= 0 {
+		return levels[i].Level
+	}
+	return -1
+}
+
+// performedSince counts the recorded certificates since the given time
+// whose performance reached at least the level at index i.
+func (s Standing) performedSince(levels []SkillLevelConfig, i int, since time.Time) int {
+	count := 0
+	for _, e := range s.History {
+		if !e.Date.Before(since) && levelIndex(levels, e.Performance) >= i {
+			count++
+		}
+	}
+	return count
+}
+
+// recordStanding adds an issued certificate to the standing of its
+// applicant on the track of the ceremony configuration.
+func recordStanding(cfg Configuration, cert Certificate) error {
+	if cert.Score == nil {
+		return nil
+	}
+
+	standings, err := loadStandings()
+	if err != nil {
+		return err
+	}
+
+	s := standings.find(cfg, cert.Applicant)
+	if s == nil {
+		standings.Applicants = append(standings.Applicants, loadStanding(cfg, cert.Applicant))
+		s = &standings.Applicants[len(standings.Applicants)-1]
+	}
+	// standings from before tracks were configured move to the first track
+	s.Track = cfg.track
+
+	// the certificate is already known when the standing was just derived
+	// from the certificates, but without its performance
+	for i, e := range s.History {
+		if e.Certificate == cert.ID.String() {
+			s.History = append(s.History[:i], s.History[i+1:]...)
+			break
+		}
+	}
+
+	s.add(cfg.SkillLevels, StandingEntry{
+		Certificate: cert.ID.String(),
+		Date:        cert.Date,
+		Performance: cert.Score.Performance,
+		Level:       cert.Score.Level,
+	})
+	return standings.save()
+}
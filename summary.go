@@ -3,11 +3,10 @@ package main
 import (
 	"fmt"
 	"io"
-	"math"
 	"os"
 	"path"
 	"path/filepath"
-	"strconv"
+	"sort"
 	"strings"
 	"time"
 
@@ -16,148 +15,211 @@ import (
 	"github.com/google/uuid"
 )
 
+// summary actions offered once all reviews are completed
 const (
-	MIN = "min"
-	MAX = "max"
-	AVG = "avg"
-	SUM = "sum"
+	SUMMARY_ACTION_ISSUE = iota
+	SUMMARY_ACTION_EDIT
+	SUMMARY_ACTION_DISCARD
+)
+
+// summary screen modes
+const (
+	SUMMARY_MODE_ACTIONS = iota
+	SUMMARY_MODE_CONFIRM_DISCARD
 )
 
 type SummaryModel struct {
-	Rank        string
-	AvgTotal    float32
-	SumTotal    float32
-	Table       table.Model
-	FieldTitles map[string]string
-	// Summaries holds computed aggregates (min/max/avg/count) per evaluation field.
-	Summaries map[string]map[string]float32
+	Mode        int
+	ActionIndex int
+	Actions     []string
+	// Error is shown above the actions, e.g. when the certificate could not
+	// be stored.
+	Error string
+
+	// Score explains the result of the completed reviews.
+	Score ScoreBreakdown
+	Table table.Model
 }
 
 func (m *Model) InitSummaryModel() {
 
-	columns := []table.Column{
-		{Title: "Bewertungsparameter", Width: 30},
-		{Title: "Ø", Width: 8},
-		{Title: "Min", Width: 8},
-		{Title: "Max", Width: 8},
-	}
-
-	fieldTitles := make(map[string]string)
-
-	for _, g := range m.Cfg.Evaluation {
-		for _, f := range g.Fields {
-			fieldTitles[f.Key] = f.Title
-		}
-	}
-
 	m.Summary = SummaryModel{
-		AvgTotal:    0.0,
-		SumTotal:    0.0,
-		FieldTitles: fieldTitles,
+		Mode: SUMMARY_MODE_ACTIONS,
+		Actions: []string{
+			SUMMARY_ACTION_ISSUE:   "Zertifikat ausstellen",
+			SUMMARY_ACTION_EDIT:    "Bewertungen korrigieren",
+			SUMMARY_ACTION_DISCARD: "Zertifizierung verwerfen",
+		},
 		Table: table.New(
-			table.WithColumns(columns),
 			table.WithFocused(true),
-			table.WithHeight(len(m.Cfg.Evaluation)+1),
 		),
-		Summaries: make(map[string]map[string]float32),
 	}
 }
 
-type sumEnvelope struct {
-	key    string
-	weigth float32
+// scoreSheets computes the score breakdown of the given reviewer sheets the
+// same way as for the certificate built from the sheets.
+func scoreSheets(cfg Configuration, reviewers map[int]RosterEntry, sheets map[int]fieldReader, standing *Standing) ScoreBreakdown {
+	return scoreQuestions(buildQuestions(cfg.Evaluation, reviewers, sheets), cfg, standing)
 }
 
-// summarizeEvaluations computes min, max and weighted average for each
-// evaluation field across all reviewers. Results are stored in
-// `m.Summaries[fieldKey]` with keys "min","max","avg","count".
-func (m *Model) summarizeEvaluations() {
+func (m *Model) UpdateSummaryModel(msg tea.Msg) []tea.Cmd {
 
-	formKeysGrouped := make(map[string][]sumEnvelope)
+	cmds := []tea.Cmd{}
 
-	for _, g := range m.Cfg.Evaluation {
-		groupKey := g.Key
-		formKeysGrouped[g.Title] = []sumEnvelope{}
-		for _, fc := range g.Fields {
-			sumEnv := sumEnvelope{
-				key:    BuildFieldKey(groupKey, fc.Key),
-				weigth: fc.Weight,
-			}
-			formKeysGrouped[g.Title] = append(formKeysGrouped[g.Title], sumEnv)
-		}
+	if m.State != STATE_SUMMARY {
+		return cmds
 	}
 
-	for k, fromKeyGroup := range formKeysGrouped {
+	m.refreshSummary()
 
-		groupKey := k
-		var minVal float32 = math.MaxFloat32
-		var maxVal float32 = -math.MaxFloat32
-		var sumVal float32 = 0
-
-		for _, form := range m.Evaluation.Forms {
-			for _, k := range fromKeyGroup {
-				if strings.HasSuffix(k.key, "_rating") {
-					val := form.GetString(k.key)
-					iVal, _ := strconv.Atoi(val)
-					iValWeighted := float32(iVal) * k.weigth
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		cmds = append(cmds, m.updateSummaryActions(msg)...)
+	}
 
-					minVal = minf(minVal, iValWeighted)
-					maxVal = maxf(maxVal, iValWeighted)
-					sumVal += iValWeighted
-				}
-			}
-		}
+	return cmds
+}
 
-		m.Summary.Summaries[groupKey] = map[string]float32{
-			MIN: float32(minVal),
-			MAX: float32(maxVal),
-			AVG: float32(sumVal) / float32(len(m.Evaluation.Forms)),
-			SUM: float32(sumVal),
+// refreshSummary recomputes the score breakdown from the completed reviews
+// and fills the summary table with one row per rated group and a column per
+// reviewer.
+func (m *Model) refreshSummary() {
+
+	// reviewers not in the roster yet are added when the certificate is
+	// issued, until then they count with the default weight
+	sheets := withEarlier(m.Evaluation.sheets(), m.DataEntry.Answers)
+	reviewers := make(map[int]RosterEntry, len(sheets))
+	for idx := range sheets {
+		reviewers[idx] = RosterEntry{Name: m.getReviewerName(idx)}
+		if known := m.Roster.find(reviewers[idx].Name); known != nil {
+			reviewers[idx] = *known
 		}
 	}
-}
-
-func (m *Model) UpdateSummaryModel(msg tea.Msg) []tea.Cmd {
-
-	cmds := []tea.Cmd{}
+	score := scoreSheets(m.ceremonyCfg(), reviewers, sheets, &m.applicantStanding)
+	m.Summary.Score = score
 
-	if m.State != STATE_SUMMARY {
-		return cmds
+	columns := []table.Column{{Title: "Bewertungsparameter", Width: 24}}
+	for _, r := range score.Reviewers {
+		columns = append(columns, table.Column{Title: truncate(r.Name, 8), Width: 8})
 	}
+	columns = append(columns,
+		table.Column{Title: "Ergebnis", Width: 8},
+		table.Column{Title: "Gewicht", Width: 7},
+		table.Column{Title: "Beitrag", Width: 7},
+	)
 
-	m.summarizeEvaluations()
-
-	var AvgTotal float32 = 0.0
 	rows := []table.Row{}
+	for _, g := range score.Groups {
+		row := table.Row{g.Title}
+		for _, r := range score.Reviewers {
+			value := "-"
+			for _, v := range g.Values {
+				if v.Name == r.Name {
+					value = formatRating(v.Value)
+				}
+			}
+			row = append(row, value)
+		}
+		row = append(row,
+			fmt.Sprintf("%.2f", g.Avg),
+			fmt.Sprintf("%.1f", g.Weight),
+			fmt.Sprintf("%.2f", g.Weighted),
+		)
+		rows = append(rows, row)
+	}
 
-	for groupTitle, summary := range m.Summary.Summaries {
-		// title, ok := m.Summary.FieldTitles[fieldKey]
-		// if !ok || title == "" {
-		// 	// fallback: use the key itself or a placeholder so UI isn't blank
-		// 	title = fieldKey
-		// }
+	row := table.Row{"Ø je Zertifizierer"}
+	for _, r := range score.Reviewers {
+		row = append(row, fmt.Sprintf("%.2f", r.Avg))
+	}
+	rows = append(rows, append(row, "", "", ""))
 
-		AvgTotal += summary[AVG]
+	// the rows must match the columns at any time
+	m.Summary.Table.SetRows(nil)
+	m.Summary.Table.SetColumns(columns)
+	m.Summary.Table.SetRows(rows)
+	m.Summary.Table.SetHeight(len(rows) + 2)
+}
 
-		rows = append(rows, table.Row{
-			groupTitle,
-			fmt.Sprintf("%.2f", summary[AVG]),
-			fmt.Sprintf("%.0f", summary[MIN]),
-			fmt.Sprintf("%.0f", summary[MAX]),
-		})
+// scoreExplanation describes how the total of the breakdown was computed
+// and which skill levels it reached.
+func scoreExplanation(score ScoreBreakdown) string {
+	var b strings.Builder
+
+	for _, g := range score.Groups {
+		if g.Aggregation != AGGREGATION_MEAN {
+			fmt.Fprintf(&b, "%s: %s\n", g.Title, g.AggregationLabel())
+		}
+		if g.Scale.String() != score.Scale.String() {
+			fmt.Fprintf(&b, "%s: Skala %s, %.2f entspricht %.2f auf der Skala %s\n", g.Title, g.Scale, g.Avg, g.Scaled, score.Scale)
+		}
 	}
+	switch {
+	case score.Formula != "":
+		fmt.Fprintf(&b, "Gesamt = %s = %.2f\n", score.Formula, score.Total)
+	case score.Aggregation == AGGREGATION_MEAN:
+		fmt.Fprintf(&b, "Gesamt = Σ Beitrag / Σ Gewicht = %.2f / %.1f = %.2f\n", score.WeightedSum(), score.WeightSum, score.Total)
+	default:
+		fmt.Fprintf(&b, "Gesamt (%s) = %.2f\n", score.AggregationLabel(), score.Total)
+	}
+	for _, level := range score.Levels {
+		mark := "✗"
+		if level.Met {
+			mark = "✓"
+		}
+		fmt.Fprintf(&b, "  %s %s (ab %.2f)\n", mark, level.Name, level.MinPoints)
+	}
+	if next := score.NextLevel(); next != nil {
+		fmt.Fprintf(&b, "%s nicht erreicht: %s\n", next.Name, strings.Join(next.Blockers, "; "))
+	}
+	return b.String()
+}
 
-	m.Summary.Table.SetRows(rows)
+// reviewerIdxs returns the reviewer idxs in numeric order.
+func (m *EvaluationModel) reviewerIdxs() []int {
+	keys := make([]int, 0, len(m.Reviewers))
+	for idx := range m.Reviewers {
+		keys = append(keys, idx)
+	}
+	sort.Ints(keys)
+	return keys
+}
 
-	Rank := ""
-	avgResult := AvgTotal / float32(len(m.Cfg.Evaluation))
-	for _, level := range m.Cfg.SkillLevels {
-		if avgResult >= level.MinPoints {
-			Rank = level.Name
+func (m *Model) updateSummaryActions(msg tea.KeyMsg) []tea.Cmd {
+	cmds := []tea.Cmd{}
+	sm := &m.Summary
+	sm.Error = ""
+
+	switch sm.Mode {
+	case SUMMARY_MODE_CONFIRM_DISCARD:
+		switch strings.ToLower(msg.String()) {
+		case "j", "y":
+			m.endCeremony("Zertifizierung verworfen.")
+			cmds = append(cmds, tea.ClearScreen)
+		case "n", "esc", "q":
+			sm.Mode = SUMMARY_MODE_ACTIONS
+		}
+	default:
+		switch msg.String() {
+		case "up", "k":
+			if sm.ActionIndex > 0 {
+				sm.ActionIndex--
+			}
+		case "down", "j":
+			if sm.ActionIndex < len(sm.Actions)-1 {
+				sm.ActionIndex++
+			}
+		case "enter":
+			switch sm.ActionIndex {
+			case SUMMARY_ACTION_ISSUE:
+				m.issueCertificate()
+				cmds = append(cmds, tea.ClearScreen)
+			case SUMMARY_ACTION_EDIT:
+				cmds = append(cmds, m.showDashboard(), tea.ClearScreen)
+			case SUMMARY_ACTION_DISCARD:
+				sm.Mode = SUMMARY_MODE_CONFIRM_DISCARD
+			}
 		}
 	}
-	m.Summary.Rank = Rank
-	m.Summary.AvgTotal = avgResult
 
 	return cmds
 }
@@ -169,108 +231,241 @@ func (m *Model) ViewSummary() (header string, body string, footer string) {
 
 	var b strings.Builder
 
-	fmt.Fprintf(&b, "\nDeine Bewertungen für %s ist %s\n", s.Highlight.Render(m.objectName), s.Highlight.Render(fmt.Sprintf("%.2f", m.Summary.AvgTotal)))
-	fmt.Fprintf(&b, "\nHerzlichen Glückwunsch %s zum %s\n", s.Highlight.Render(m.applicantName), s.Highlight.Render(m.Summary.Rank))
+	score := m.Summary.Score
+	fmt.Fprintf(&b, "\nDeine Bewertungen für %s ist %s\n", s.Highlight.Render(m.objectName), s.Highlight.Render(fmt.Sprintf("%.2f", score.Total)))
+	fmt.Fprintf(&b, "\nHerzlichen Glückwunsch %s zum %s\n", s.Highlight.Render(m.applicantName), s.Highlight.Render(score.Level))
+	if st := m.applicantStanding; len(st.History) > 0 {
+		level := st.Level
+		if level == "" {
+			level = "kein Level"
+		}
+		fmt.Fprintf(&b, "Bisheriger Stand: %s aus %d Zertifikaten\n", s.Highlight.Render(level), len(st.History))
+	}
+	if m.recertifies != "" {
+		fmt.Fprintf(&b, "Rezertifizierung von Zertifikat %s\n", m.recertifies)
+	}
 
 	b.WriteString(s.Base.Render(m.Summary.Table.View()))
+	b.WriteString("\n\n")
+	b.WriteString(scoreExplanation(score))
+	b.WriteString("\n")
+
+	if m.Summary.Error != "" {
+		fmt.Fprintf(&b, "%s\n\n", s.ErrorHeaderText.Render(m.Summary.Error))
+	}
+
+	switch m.Summary.Mode {
+	case SUMMARY_MODE_CONFIRM_DISCARD:
+		fmt.Fprintf(&b, "Soll die Zertifizierung wirklich verworfen werden? %s\n",
+			s.Highlight.Render("Alle Bewertungen gehen verloren."))
+		footer = m.appErrorBoundaryView("j: Verwerfen · n/Esc: Zurück")
+	default:
+		for i, action := range m.Summary.Actions {
+			if i == m.Summary.ActionIndex {
+				fmt.Fprintf(&b, "> %s\n", s.Highlight.Render(action))
+			} else {
+				fmt.Fprintf(&b, "  %s\n", action)
+			}
+		}
+		footer = m.appBoundaryView("Navigiere mit Pfeiltasten, Bestätige mit Enter")
+	}
 
-	body = b.String() + "\n\n"
-	footer = m.appBoundaryView("Drücken Sie 'q' oder 'Esc', um die Anwendung zu beenden.")
+	body = b.String() + "\n"
 
 	return header, body, footer
 }
 
-func (m *Model) CreateCertificate() {
+// CreateCertificate stores the certificate of the finished ceremony and
+// returns the path of its YAML file, or "" if it could not be stored.
+func (m *Model) CreateCertificate() string {
 
 	if m.State != STATE_SUMMARY {
-		return
+		return ""
+	}
+
+	sheets := withEarlier(m.Evaluation.sheets(), m.DataEntry.Answers)
+	names := make(map[int]string)
+	for idx := range sheets {
+		names[idx] = m.getReviewerName(idx)
+	}
+
+	cfg := m.ceremonyCfg()
+	certificate := buildCertificate(cfg, m.applicantName, m.objectName, resolveReviewers(names), sheets)
+	certificate.Recertifies = m.recertifies
+	certificate.ObjectClass = m.objectClass()
+
+	certificatePath, err := storeCertificate(certificate, m.objectImage, cfg)
+	if err != nil {
+		logger.Printf("Failed to store certificate %s: %v", certificate.ID, err)
+		return ""
 	}
 
+	return certificatePath
+}
+
+// resolveReviewers looks up the reviewers keyed by reviewer idx in the
+// roster, adding those not known yet. If the roster cannot be read or
+// written the reviewers are returned without roster IDs.
+func resolveReviewers(names map[int]string) map[int]RosterEntry {
+	res := make(map[int]RosterEntry, len(names))
+
+	roster, err := loadRoster()
+	if err != nil {
+		logger.Printf("Failed to load reviewer roster: %v", err)
+		for idx, name := range names {
+			res[idx] = RosterEntry{Name: name}
+		}
+		return res
+	}
+
+	changed := false
+	for idx, name := range names {
+		e, added := roster.resolve(name)
+		changed = changed || added
+		res[idx] = e
+	}
+
+	if changed {
+		if err := roster.save(); err != nil {
+			logger.Printf("Failed to save reviewer roster: %v", err)
+		}
+	}
+	return res
+}
+
+// buildCertificate assembles a new certificate from the reviewer sheets.
+// reviewers and sheets are both keyed by reviewer idx.
+func buildCertificate(cfg Configuration, applicantName string, objectName string, reviewers map[int]RosterEntry, sheets map[int]fieldReader) Certificate {
+
 	certificate := Certificate{
 		ID:         uuid.New(),
 		Date:       time.Now(),
-		Applicant:  m.applicantName,
-		ObjectName: m.objectName,
+		Applicant:  applicantName,
+		ObjectName: objectName,
 		Reviewers:  make([]string, 0),
 		Questions:  make([]CertificateQuestion, 0),
 	}
 
-	for _, reviewer := range m.Evaluation.Reviewers {
-		reviewerName := m.DataEntry.Form.GetString(reviewer.key)
-		if strings.TrimSpace(reviewerName) == "" {
-			reviewerName = reviewer.key
+	// list the reviewers in panel order
+	idxs := make([]int, 0, len(reviewers))
+	for idx := range reviewers {
+		idxs = append(idxs, idx)
+	}
+	sort.Ints(idxs)
+	for _, idx := range idxs {
+		certificate.Reviewers = append(certificate.Reviewers, reviewers[idx].Name)
+		if reviewers[idx].ID != "" {
+			certificate.ReviewerIDs = append(certificate.ReviewerIDs, reviewers[idx].ID)
 		}
-		certificate.Reviewers = append(certificate.Reviewers, reviewerName)
+	}
+	if len(certificate.ReviewerIDs) != len(certificate.Reviewers) {
+		certificate.ReviewerIDs = nil
 	}
 
-	formKeysGrouped := make(map[string][]string)
-	for _, g := range m.Cfg.Evaluation {
-		groupKey := g.Key
-		formKeysGrouped[g.Title] = []string{}
-		for _, fc := range g.Fields {
-			fieldKey := BuildFieldKey(groupKey, fc.Key)
-			var appendFieldKey bool = false
-			if fc.Type == "range" && strings.HasSuffix(fieldKey, "_rating") {
-				appendFieldKey = true
-			} else if fc.Type == "text" && strings.HasSuffix(fieldKey, "_comment") {
-				appendFieldKey = true
-			}
+	certificate.Questions = buildQuestions(cfg.Evaluation, reviewers, sheets)
+	certificate.Track = cfg.track
+	standing := loadStanding(cfg, applicantName)
+	score := scoreQuestions(certificate.Questions, cfg, &standing)
+	certificate.Score = &score
+	certificate.ValidUntil = validUntil(cfg.SkillLevels, score.Level, certificate.Date)
 
-			if appendFieldKey {
-				formKeysGrouped[g.Title] = append(formKeysGrouped[g.Title], fieldKey)
-			}
-		}
-	}
+	return certificate
+}
 
-	for groupTitle, formKeyGroup := range formKeysGrouped {
+// buildQuestions collects the answers of the sheets into one question per
+// evaluation group, in the order of the groups. Responses follow the panel
+// order.
+func buildQuestions(groups []GroupConfig, reviewers map[int]RosterEntry, sheets map[int]fieldReader) []CertificateQuestion {
+	questions := make([]CertificateQuestion, 0, len(groups))
 
-		if len(formKeyGroup) != 2 {
-			panic("expected rating & comment field per group")
-		}
+	sheetIdxs := make([]int, 0, len(sheets))
+	for idx := range sheets {
+		sheetIdxs = append(sheetIdxs, idx)
+	}
+	sort.Ints(sheetIdxs)
+
+	for _, g := range groups {
+		fcRatingKey := g.FieldKey(ROLE_SCORE)
+		fcCommentKey := g.FieldKey(ROLE_COMMENT)
 
 		certificateQuestion := CertificateQuestion{
-			Question:  groupTitle,
-			Responses: []CertificateResponse{},
+			Question:    g.Title,
+			Key:         g.Key,
+			Unscored:    fcRatingKey == "",
+			Aggregation: g.Aggregation,
+			Responses:   []CertificateResponse{},
 		}
-
-		var fcRatingKey, fcCommentKey string
-		for _, fk := range formKeyGroup {
-			if strings.HasSuffix(fk, "_rating") {
-				fcRatingKey = fk
-			} else if strings.HasSuffix(fk, "_comment") {
-				fcCommentKey = fk
+		for _, fc := range g.Fields {
+			if fc.Role == ROLE_SCORE {
+				certificateQuestion.Weight = effectiveWeight(float64(fc.Weight))
+				certificateQuestion.Scale = fc.Scale
 			}
 		}
 
-		for reviewerIdx, form := range m.Evaluation.Forms {
-
-			reviewerName := m.getReviewerName(reviewerIdx)
-			commentVal := form.GetString(fcCommentKey)
-			ratingVal := 0
-			if rv, err := strconv.Atoi(form.GetString(fcRatingKey)); err == nil {
-				ratingVal = rv
+		for _, reviewerIdx := range sheetIdxs {
+			form := sheets[reviewerIdx]
+			if !g.ShowIf.hold(g.Key, form) {
+				continue
 			}
 
+			reviewer := reviewers[reviewerIdx]
 			response := CertificateResponse{
-				Name:    reviewerName,
-				Value:   ratingVal,
-				Comment: commentVal,
+				Name:           reviewer.Name,
+				ReviewerID:     reviewer.ID,
+				ReviewerWeight: reviewer.Weight,
+			}
+			if fcCommentKey != "" {
+				response.Comment = form.GetString(fcCommentKey)
+			}
+			if fcRatingKey != "" {
+				if rv, err := parseRating(form.GetString(fcRatingKey)); err == nil {
+					response.Value = rv
+				}
+			}
+
+			for _, fc := range g.Fields {
+				v, ok := typedAnswer(fc, form.Get(BuildFieldKey(g.Key, fc.Key)))
+				if !ok {
+					continue
+				}
+				answer := CertificateAnswer{
+					Key:   fc.Key,
+					Title: fc.Title,
+					Type:  fc.Type,
+					Role:  fc.Role,
+					Value: v,
+				}
+				answer.Label = answerLabel(fc, v)
+				response.Answers = append(response.Answers, answer)
 			}
 
 			certificateQuestion.Responses = append(certificateQuestion.Responses, response)
 		}
 
-		certificate.Questions = append(certificate.Questions, certificateQuestion)
+		// groups hidden from all reviewers are left out
+		if len(g.ShowIf) > 0 && len(certificateQuestion.Responses) == 0 {
+			continue
+		}
+		questions = append(questions, certificateQuestion)
 	}
 
+	return questions
+}
+
+// storeCertificate writes the certificate YAML into the certificates folder
+// (`<year>/<month>/<id>.yaml`) together with a copy of the object image and
+// returns the path of the YAML file. The certificate is added to the
+// applicant's standing on the track of the ceremony configuration cfg.
+func storeCertificate(certificate Certificate, objectImage string, cfg Configuration) (string, error) {
+
 	currentPath := path.Join(getCertificatesPath(), certificate.Date.Format("2006"), certificate.Date.Format("01"))
 	currentCertificatePath := path.Join(currentPath, certificate.ID.String()+".yaml")
 
 	os.MkdirAll(currentPath, os.ModePerm)
 
-	// Determine source image: prefer user-selected `m.objectImage` if present
+	// Determine source image: prefer user-selected `objectImage` if present
 	// and exists; otherwise fall back to the app asset `assets/designer.png`.
-	sourceImage := strings.TrimSpace(m.objectImage)
+	sourceImage := strings.TrimSpace(objectImage)
 	if sourceImage == "" {
 		// try app asset
 		appAsset := filepath.Join(getAppBasePath(), "assets", "designer.png")
@@ -324,5 +519,13 @@ func (m *Model) CreateCertificate() {
 		}
 	}
 
-	saveCertificate(currentCertificatePath, certificate)
+	if err := saveCertificate(currentCertificatePath, certificate); err != nil {
+		return "", err
+	}
+
+	if err := recordStanding(cfg, certificate); err != nil {
+		logger.Printf("Failed to update standing of %s: %v", certificate.Applicant, err)
+	}
+
+	return currentCertificatePath, nil
 }
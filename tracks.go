@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TrackConfig is an independent ladder of skill levels, e.g.
+//
+//	tracks:
+//	- key: backen
+//	  name: Backen
+//	  skilllevels:
+//	  - level: 1
+//	    name: Geselle
+//	    min_points: 3
+//	- key: dekor
+//	  name: Dekoration
+//	  skilllevels: ...
+//
+// A ceremony runs on the track chosen in the data collection field with role
+// track. Certificates record the key of their track and standings are kept
+// per track.
+type TrackConfig struct {
+	Key         string             `yaml:"key"`
+	Name        string             `yaml:"name,omitempty"`
+	SkillLevels []SkillLevelConfig `yaml:"skilllevels"`
+
+	pos position
+}
+
+// Title returns the name of the track, its key if it has none.
+func (t TrackConfig) Title() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Key
+}
+
+// tracks returns the configured tracks. Without tracks the top-level skill
+// levels form a single track without a key.
+func (c Configuration) tracks() []TrackConfig {
+	if len(c.Tracks) == 0 {
+		return []TrackConfig{{SkillLevels: c.SkillLevels}}
+	}
+	return c.Tracks
+}
+
+// findTrack returns the track whose key or name equals track compared
+// case-insensitively.
+func (c Configuration) findTrack(track string) (TrackConfig, bool) {
+	track = strings.TrimSpace(track)
+	for _, t := range c.tracks() {
+		if track != "" && (strings.EqualFold(t.Key, track) || strings.EqualFold(t.Name, track)) {
+			return t, true
+		}
+	}
+	return TrackConfig{}, false
+}
+
+// TrackOf returns the track a certificate or standing recorded with the
+// given track belongs to. Records without a track, e.g. from before tracks
+// were configured, and records of unknown tracks belong to the first track.
+func (c Configuration) TrackOf(track string) TrackConfig {
+	if t, ok := c.findTrack(track); ok {
+		return t
+	}
+	return c.tracks()[0]
+}
+
+// onTrack reports whether a record of the given track belongs to the track
+// of a configuration returned by ForCeremony.
+func (c Configuration) onTrack(track string) bool {
+	return c.TrackOf(track).Key == c.track
+}
+
+// ForCeremony returns the configuration of a ceremony on the given track
+// for an object of the given class: SkillLevels holds the levels of the
+// track, adapted to the class as described on ForClass.
+func (c Configuration) ForCeremony(track, class string) Configuration {
+	t := c.TrackOf(track)
+	res := c
+	res.SkillLevels = t.SkillLevels
+	res.track = t.Key
+	return res.ForClass(class)
+}
+
+// trackOption returns the option of the track field choosing the track a
+// record of the given track belongs to, empty without a track field.
+func (c Configuration) trackOption(track string) string {
+	key := c.TrackOf(track).Key
+	for _, g := range c.DataCollection {
+		for _, fc := range g.Fields {
+			if fc.Role != ROLE_TRACK {
+				continue
+			}
+			for _, o := range fc.Options {
+				if c.TrackOf(o).Key == key {
+					return o
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// applyTrackOptions offers the tracks in the track field if it doesn't
+// list options itself.
+func (c *Configuration) applyTrackOptions() {
+	for _, g := range c.DataCollection {
+		for i, fc := range g.Fields {
+			if fc.Role != ROLE_TRACK || len(fc.Options) > 0 {
+				continue
+			}
+			for _, t := range c.Tracks {
+				g.Fields[i].Options = append(g.Fields[i].Options, t.Title())
+			}
+		}
+	}
+}
+
+// validateTracks checks the tracks, the levels of each track and the field
+// choosing the track.
+func (c Configuration) validateTracks() error {
+	if c.track != "" {
+		// the configuration of a ceremony, its levels are those of the track
+		return nil
+	}
+	var field *FieldConfig
+	for _, g := range c.DataCollection {
+		for i := range g.Fields {
+			if g.Fields[i].Role == ROLE_TRACK {
+				field = &g.Fields[i]
+			}
+		}
+	}
+
+	if len(c.Tracks) == 0 {
+		if field != nil {
+			return field.pos.errorf("datacollection %s: role %q requires tracks", field.Key, ROLE_TRACK)
+		}
+		return nil
+	}
+	if len(c.SkillLevels) > 0 {
+		return fmt.Errorf("skilllevels: cannot be combined with tracks, move the levels into a track")
+	}
+
+	seen := make(map[string]bool)
+	for _, t := range c.Tracks {
+		where := fmt.Sprintf("tracks %q", t.Title())
+		if strings.TrimSpace(t.Key) == "" {
+			return t.pos.errorf("tracks: entry without key")
+		}
+		// key and name identify the track, they may be the same
+		ids := map[string]bool{strings.ToLower(t.Key): true}
+		if t.Name != "" {
+			ids[strings.ToLower(t.Name)] = true
+		}
+		for id := range ids {
+			if seen[id] {
+				return t.pos.errorf("%s: %q is used by more than one track", where, id)
+			}
+		}
+		for id := range ids {
+			seen[id] = true
+		}
+		if len(t.SkillLevels) == 0 {
+			return t.pos.errorf("%s: no skill levels", where)
+		}
+		if err := c.ForCeremony(t.Key, "").validateLevels(); err != nil {
+			return t.pos.wrap(fmt.Errorf("%s: %w", where, err))
+		}
+	}
+
+	if field == nil {
+		if len(c.Tracks) > 1 {
+			return fmt.Errorf("tracks: the data collection has no field with role %s", ROLE_TRACK)
+		}
+		return nil
+	}
+	if field.Type != "select" {
+		return field.pos.errorf("datacollection %s: role %q requires a field of type select", field.Key, ROLE_TRACK)
+	}
+	for _, o := range field.Options {
+		if _, ok := c.findTrack(o); !ok {
+			return field.pos.errorf("datacollection %s: option %q is no track", field.Key, o)
+		}
+	}
+	return nil
+}
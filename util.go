@@ -27,3 +27,15 @@ func maxf(x, y float32) float32 {
 	}
 	return y
 }
+
+// truncate shortens s to at most width runes, marking the cut with "…".
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(r[:max(width, 0)])
+	}
+	return string(r[:width-1]) + "…"
+}
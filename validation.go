@@ -0,0 +1,299 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayouts are the accepted spellings of date fields, the first one is
+// the one stored.
+var dateLayouts = []string{"2006-01-02", "02.01.2006"}
+
+// parseDate parses the value of a date field.
+func parseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is no date, expected YYYY-MM-DD or DD.MM.YYYY", s)
+}
+
+// parseNumber parses the value of a number field, accepting a decimal comma.
+func parseNumber(s string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", "."), 64)
+}
+
+// stringValidator returns the validator attached to string valued fields
+// (range, input, select, text, filepicker, number, date and the statements
+// of likert fields) or nil if the field has none. The conditions of the
+// field's rules are evaluated against form, the values of the form the field
+// belongs to.
+func stringValidator(fc FieldConfig, groupKey string, form fieldReader) func(string) error {
+	var checks []func(string) error
+	if fc.Mandatory {
+		checks = append(checks, func(s string) error {
+			if strings.TrimSpace(s) == "" {
+				return fmt.Errorf("%s is required", fc.Title)
+			}
+			return nil
+		})
+	}
+	switch fc.Type {
+	case "number":
+		checks = append(checks, func(s string) error {
+			if strings.TrimSpace(s) == "" {
+				return nil
+			}
+			n, err := parseNumber(s)
+			if err != nil {
+				return fmt.Errorf("%s: %q is no number", fc.Title, s)
+			}
+			if fc.Min != nil && n < *fc.Min {
+				return fmt.Errorf("%s must be at least %s", fc.Title, formatRating(*fc.Min))
+			}
+			if fc.Max != nil && n > *fc.Max {
+				return fmt.Errorf("%s must be at most %s", fc.Title, formatRating(*fc.Max))
+			}
+			return nil
+		})
+	case "date":
+		checks = append(checks, func(s string) error {
+			if strings.TrimSpace(s) == "" {
+				return nil
+			}
+			if _, err := parseDate(s); err != nil {
+				return fmt.Errorf("%s: %w", fc.Title, err)
+			}
+			return nil
+		})
+	}
+	if len(fc.Rules) > 0 {
+		checks = append(checks, func(s string) error {
+			return checkRules(fc, groupKey, form, s)
+		})
+	}
+	if len(checks) == 0 {
+		return nil
+	}
+
+	return func(s string) error {
+		for _, check := range checks {
+			if err := check(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// boolValidator returns the validator attached to confirm fields or nil if
+// the field has none.
+func boolValidator(fc FieldConfig) func(bool) error {
+	if !fc.RequireYes {
+		return nil
+	}
+
+	return func(v bool) error {
+		if !v {
+			return fmt.Errorf("Welp, finish up then")
+		}
+		return nil
+	}
+}
+
+// listValidator returns the validator of the list valued fields
+// (multiselect, ranking and likert) or nil if the field has none. Ranking
+// and likert values hold one entry per place or statement, empty if not
+// answered. Rules are evaluated like for stringValidator.
+func listValidator(fc FieldConfig, groupKey string, form fieldReader) func([]string) error {
+	switch fc.Type {
+	case "ranking":
+		return func(s []string) error {
+			var placed []string
+			for _, v := range s {
+				if v == "" {
+					continue
+				}
+				if slices.Contains(placed, v) {
+					return fmt.Errorf("%s: %q is ranked twice", fc.Title, v)
+				}
+				placed = append(placed, v)
+			}
+			if fc.Mandatory && len(placed) < len(fc.Options) {
+				return fmt.Errorf("%s: all of %s must be ranked", fc.Title, strings.Join(fc.Options, ", "))
+			}
+			return nil
+		}
+	case "likert":
+		if !fc.Mandatory {
+			return nil
+		}
+		return func(s []string) error {
+			for i, statement := range fc.Statements {
+				if i >= len(s) || strings.TrimSpace(s[i]) == "" {
+					return fmt.Errorf("%s: %q is required", fc.Title, statement)
+				}
+			}
+			return nil
+		}
+	}
+
+	if !fc.Mandatory && len(fc.Rules) == 0 {
+		return nil
+	}
+
+	return func(s []string) error {
+		if fc.Mandatory && len(s) == 0 {
+			return fmt.Errorf("%s is required", fc.Title)
+		}
+		return checkRules(fc, groupKey, form, s)
+	}
+}
+
+// rankValidator returns the validator of the i-th place of a ranking field,
+// which rejects an option already ranked on another place.
+func rankValidator(fc FieldConfig, slots []*string, i int) func(string) error {
+	return func(s string) error {
+		if fc.Mandatory && s == "" {
+			return fmt.Errorf("%s: %d. Platz is required", fc.Title, i+1)
+		}
+		for j, other := range slots {
+			if j != i && s != "" && *other == s {
+				return fmt.Errorf("%s: %q is already on place %d", fc.Title, s, j+1)
+			}
+		}
+		return nil
+	}
+}
+
+// allowedValues returns the values a select-like field accepts or nil if
+// the field accepts free input.
+func allowedValues(fc FieldConfig) []string {
+	switch fc.Type {
+	case "range", "likert":
+		var values []string
+		for _, o := range rangeOptions(fc) {
+			values = append(values, o.Value)
+		}
+		return values
+	case "select", "multiselect", "ranking":
+		if len(fc.Options) > 0 {
+			return fc.Options
+		}
+	}
+	return nil
+}
+
+// normalizeAnswer converts a value decoded from an answers file into the
+// type the field's form widget would have produced.
+func normalizeAnswer(fc FieldConfig, raw any) (any, error) {
+	switch fc.Type {
+	case "confirm":
+		if raw == nil {
+			return false, nil
+		}
+		b, ok := raw.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected yes/no, got %v", fc.Title, raw)
+		}
+		return b, nil
+	case "multiselect", "ranking":
+		var vs []string
+		switch t := raw.(type) {
+		case nil:
+		case []any:
+			for _, v := range t {
+				vs = append(vs, fmt.Sprint(v))
+			}
+		case []string:
+			vs = t
+		case string:
+			vs = append(vs, t)
+		default:
+			return nil, fmt.Errorf("%s: expected a list, got %v", fc.Title, raw)
+		}
+		return vs, nil
+	case "likert":
+		// either a map keyed by statement or a list in the order of the
+		// statements
+		vs := make([]string, len(fc.Statements))
+		switch t := raw.(type) {
+		case nil:
+		case map[string]any:
+			for k, v := range t {
+				i := slices.Index(fc.Statements, k)
+				if i < 0 {
+					return nil, fmt.Errorf("%s: unknown statement %q", fc.Title, k)
+				}
+				vs[i] = fmt.Sprint(v)
+			}
+		case []any:
+			if len(t) > len(vs) {
+				return nil, fmt.Errorf("%s: expected at most %d ratings, got %d", fc.Title, len(vs), len(t))
+			}
+			for i, v := range t {
+				if v != nil {
+					vs[i] = fmt.Sprint(v)
+				}
+			}
+		case []string:
+			copy(vs, t)
+		default:
+			return nil, fmt.Errorf("%s: expected a rating per statement, got %v", fc.Title, raw)
+		}
+		return vs, nil
+	default:
+		switch t := raw.(type) {
+		case nil:
+			return "", nil
+		case time.Time:
+			return t.Format(dateLayouts[0]), nil
+		case []any, map[string]any:
+			return nil, fmt.Errorf("%s: expected a single value, got %v", fc.Title, raw)
+		default:
+			return fmt.Sprint(t), nil
+		}
+	}
+}
+
+// validateAnswer runs the same checks against value that buildGroups
+// attaches to the field's form widget, form holds the other values of the
+// form. Select-like fields additionally have their value checked against the
+// offered options since there is no widget restricting the choice.
+func validateAnswer(fc FieldConfig, groupKey string, form fieldReader, value any) error {
+	allowed := allowedValues(fc)
+
+	switch v := value.(type) {
+	case string:
+		if validate := stringValidator(fc, groupKey, form); validate != nil {
+			if err := validate(v); err != nil {
+				return err
+			}
+		}
+		if v != "" && allowed != nil && !slices.Contains(allowed, v) {
+			return fmt.Errorf("%s: %q is not one of %s", fc.Title, v, strings.Join(allowed, ", "))
+		}
+	case bool:
+		if validate := boolValidator(fc); validate != nil {
+			return validate(v)
+		}
+	case []string:
+		if validate := listValidator(fc, groupKey, form); validate != nil {
+			if err := validate(v); err != nil {
+				return err
+			}
+		}
+		for _, s := range v {
+			if s != "" && allowed != nil && !slices.Contains(allowed, s) {
+				return fmt.Errorf("%s: %q is not one of %s", fc.Title, s, strings.Join(allowed, ", "))
+			}
+		}
+	}
+
+	return nil
+}
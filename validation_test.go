@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+)
+
+func rankingGroup(mandatory bool) []GroupConfig {
+	return []GroupConfig{{Key: "favourites", Fields: []FieldConfig{{
+		Type:      "ranking",
+		Key:       "cakes",
+		Title:     "Lieblingskuchen",
+		Mandatory: mandatory,
+		Options:   []string{"Apfel", "Kirsch", "Käse"},
+	}}}}
+}
+
+// submitRanking passes all places of the ranking without choosing an
+// option and returns the form and its values.
+func submitRanking(t *testing.T, groups []GroupConfig) (*huh.Form, fieldBindings) {
+	t.Helper()
+	m := newTestModel(t)
+	hg, values := m.buildGroups(groups, nil, nil)
+	form := huh.NewForm(hg...)
+	form.Init()
+	// the note and one select per place
+	for i := 0; i <= len(groups[0].Fields[0].Options); i++ {
+		model, _ := form.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		form = model.(*huh.Form)
+	}
+	return form, values
+}
+
+func TestMandatoryRankingStartsEmpty(t *testing.T) {
+	form, values := submitRanking(t, rankingGroup(true))
+	if form.State == huh.StateCompleted {
+		t.Fatal("mandatory ranking completed without choosing a place")
+	}
+	if len(form.Errors()) == 0 {
+		t.Error("no error for the empty place")
+	}
+	for _, v := range values.sheet()[BuildFieldKey("favourites", "cakes")].([]string) {
+		if v != "" {
+			t.Errorf("place preset to %q", v)
+		}
+	}
+}
+
+func TestOptionalRankingMayStayEmpty(t *testing.T) {
+	form, values := submitRanking(t, rankingGroup(false))
+	if errs := form.Errors(); len(errs) > 0 {
+		t.Fatalf("optional ranking rejected: %v", errs)
+	}
+	if _, ok := typedAnswer(rankingGroup(false)[0].Fields[0], values.sheet()[BuildFieldKey("favourites", "cakes")]); ok {
+		t.Error("empty ranking stored as answer")
+	}
+}